@@ -0,0 +1,32 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// AniList list statuses in the order the status key cycles through them
+var listStatuses = []string{"CURRENT", "PLANNING", "COMPLETED", "PAUSED", "DROPPED", "REPEATING"}
+
+// listEntrySavedMsg carries the outcome of a SaveMediaListEntry mutation.
+// prev is the entry as it was before the optimistic update so that it can be
+// restored if the mutation fails. seq numbers the saves of the anime, see
+// nextEntrySave.
+type listEntrySavedMsg struct {
+	seq   int
+	prev  UserAnimeEntry
+	entry UserAnimeEntry
+	err   error
+}
+
+func saveMediaListEntry(tracker Tracker, seq int, prev, entry UserAnimeEntry) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		saved, err := tracker.SaveEntry(ctx, entry)
+		if err != nil {
+			return listEntrySavedMsg{seq: seq, prev: prev, entry: entry, err: err}
+		}
+		return listEntrySavedMsg{seq: seq, prev: prev, entry: *saved}
+	}
+}
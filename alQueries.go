@@ -1,169 +1,177 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
+	"context"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sunnygitgud/sakuhaku/anilist"
 )
 
-func fetchTrendingAnime() tea.Cmd {
+// How long a single AniList command may take, including rate limit backoff
+const aniListTimeout = 90 * time.Second
+
+// Page size of the browse lists
+const browsePerPage = 50
+
+// aniListErrorMsg reports a failed AniList request so it can be shown
+// instead of an empty list
+type aniListErrorMsg struct {
+	err error
+}
+
+func aniListContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), aniListTimeout)
+}
+
+func fetchTrendingAnime(client *anilist.Client, listType ListType, page int) tea.Cmd {
 	return func() tea.Msg {
-		query := `
-		query {
-			Page(page: 1, perPage: 50) {
-				media(type: ANIME, sort: TRENDING_DESC) {
-					id
-					title {
-						romaji
-						english
-					}
-					format
-					status
-					episodes
-					averageScore
-					season
-					seasonYear
-					coverImage {
-						large
-					}
-					siteUrl
-				}
-			}
-		}
-		`
+		ctx, cancel := aniListContext()
+		defer cancel()
 
-		result, err := makePublicRequest(query, nil)
+		result, err := client.Trending(ctx, page, browsePerPage)
 		if err != nil {
-			return userListMsg(nil)
+			return aniListErrorMsg{err: err}
 		}
-
-		var entries []UserAnimeEntry
-		for _, anime := range result.Data.Page.Media {
-			entries = append(entries, UserAnimeEntry{
-				Media: anime,
-			})
+		return userListMsg{
+			listType:    listType,
+			entries:     entriesFromMedia(result.Media),
+			page:        page,
+			hasNextPage: result.PageInfo.HasNextPage,
 		}
-
-		return userListMsg(entries)
 	}
 }
 
-func fetchPopularThisSeason() tea.Cmd {
+func fetchPopularThisSeason(client *anilist.Client, listType ListType, page int) tea.Cmd {
 	return func() tea.Msg {
-		now := time.Now()
-		season, year := getCurrentSeason(now)
-
-		query := `
-		query ($season: MediaSeason, $year: Int) {
-			Page(page: 1, perPage: 50) {
-				media(type: ANIME, season: $season, seasonYear: $year, sort: POPULARITY_DESC) {
-					id
-					title {
-						romaji
-						english
-					}
-					format
-					status
-					episodes
-					averageScore
-					season
-					seasonYear
-					coverImage {
-						large
-					}
-					siteUrl
-				}
-			}
-		}
-		`
+		ctx, cancel := aniListContext()
+		defer cancel()
 
-		variables := map[string]interface{}{
-			"season": season,
-			"year":   year,
+		season, year := getCurrentSeason(time.Now())
+		result, err := client.PopularThisSeason(ctx, season, year, page, browsePerPage)
+		if err != nil {
+			return aniListErrorMsg{err: err}
 		}
+		return userListMsg{
+			listType:    listType,
+			entries:     entriesFromMedia(result.Media),
+			page:        page,
+			hasNextPage: result.PageInfo.HasNextPage,
+		}
+	}
+}
 
-		result, err := makePublicRequest(query, variables)
+func fetchTopRated(client *anilist.Client, listType ListType, page int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		result, err := client.TopRated(ctx, page, browsePerPage)
 		if err != nil {
-			return userListMsg(nil)
+			return aniListErrorMsg{err: err}
 		}
-
-		var entries []UserAnimeEntry
-		for _, anime := range result.Data.Page.Media {
-			entries = append(entries, UserAnimeEntry{
-				Media: anime,
-			})
+		return userListMsg{
+			listType:    listType,
+			entries:     entriesFromMedia(result.Media),
+			page:        page,
+			hasNextPage: result.PageInfo.HasNextPage,
 		}
-
-		return userListMsg(entries)
 	}
 }
 
-func fetchTopRated() tea.Cmd {
+type animeDetailMsg struct {
+	detail *AnimeDetail
+	err    error
+}
+
+func fetchAnimeDetail(client *anilist.Client, id int) tea.Cmd {
 	return func() tea.Msg {
-		query := `
-		query {
-			Page(page: 1, perPage: 50) {
-				media(type: ANIME, sort: SCORE_DESC) {
-					id
-					title {
-						romaji
-						english
-					}
-					format
-					status
-					episodes
-					averageScore
-					season
-					seasonYear
-					coverImage {
-						large
-					}
-					siteUrl
-				}
-			}
-		}
-		`
+		ctx, cancel := aniListContext()
+		defer cancel()
 
-		result, err := makePublicRequest(query, nil)
+		detail, err := client.Media(ctx, id)
 		if err != nil {
-			return userListMsg(nil)
+			return animeDetailMsg{err: err}
 		}
+		return animeDetailMsg{detail: detail}
+	}
+}
 
-		var entries []UserAnimeEntry
-		for _, anime := range result.Data.Page.Media {
-			entries = append(entries, UserAnimeEntry{
-				Media: anime,
-			})
+// revalidate shows the cached result of fetch right away, then refreshes it
+// from AniList unless the cached copy is still fresh. When the cache answered,
+// list results of the refresh are marked as background refreshes.
+func revalidate(client *anilist.Client, fetch func(*anilist.Client) tea.Cmd) tea.Cmd {
+	cachedCmd, networkCmd := fetch(client.WithCachePolicy(anilist.CacheOnly)), fetch(client)
+	var cached atomic.Bool
+	return tea.Sequence(func() tea.Msg {
+		msg := cachedCmd()
+		_, failed := msg.(aniListErrorMsg)
+		cached.Store(!failed)
+		return msg
+	}, func() tea.Msg {
+		msg := networkCmd()
+		if !cached.Load() {
+			return msg
+		}
+		switch msg := msg.(type) {
+		case userListMsg:
+			msg.refresh = true
+			return msg
+		case collectionMsg:
+			msg.refresh = true
+			return msg
 		}
+		return msg
+	})
+}
+
+func (m *model) fetchCurrentList() tea.Cmd {
+	return m.fetchListPage(1)
+}
 
-		return userListMsg(entries)
+// refreshCurrentList reloads the current list from AniList, ignoring the cache
+// unless AniList can't be reached
+func (m *model) refreshCurrentList() tea.Cmd {
+	return m.listFetcher(1)(m.aniList.WithCachePolicy(anilist.NetworkFirst))
+}
+
+// fetchListPage loads one page of the current list type. The first page is
+// shown from the cache while it is refreshed.
+func (m *model) fetchListPage(page int) tea.Cmd {
+	fetch := m.listFetcher(page)
+	if page > 1 {
+		return fetch(m.aniList)
 	}
+	return revalidate(m.aniList, fetch)
 }
 
-func (m *model) fetchCurrentList() tea.Cmd {
-	switch m.currentListType {
-	case ListCurrentlyWatching:
-		if m.accessToken == "" {
-			return fetchTrendingAnime()
-		}
-		return fetchUserAnimeList(m.accessToken, m.userID, "CURRENT")
-	case ListPlanToWatch:
-		if m.accessToken == "" {
-			return fetchTrendingAnime()
-		}
-		return fetchUserAnimeList(m.accessToken, m.userID, "PLANNING")
-	case ListTrending:
-		return fetchTrendingAnime()
-	case ListPopularSeason:
-		return fetchPopularThisSeason()
-	case ListTopRated:
-		return fetchTopRated()
+// listFetcher returns the command loading one page of the current list type.
+// Personal lists are all loaded at once with the user's collection, which is
+// the local list without a login.
+func (m *model) listFetcher(page int) func(*anilist.Client) tea.Cmd {
+	lt := m.currentListType
+	userID := m.userID
+	switch {
+	case lt.Personal():
+		return func(c *anilist.Client) tea.Cmd { return fetchUserCollection(m.listTrackerWith(c), userID) }
+	case lt == ListPopularSeason:
+		return func(c *anilist.Client) tea.Cmd { return fetchPopularThisSeason(c, lt, page) }
+	case lt == ListTopRated:
+		return func(c *anilist.Client) tea.Cmd { return fetchTopRated(c, lt, page) }
 	default:
+		return func(c *anilist.Client) tea.Cmd { return fetchTrendingAnime(c, lt, page) }
+	}
+}
+
+// loadMoreIfAtEnd requests the next page once the cursor reaches the last entry
+func (m *model) loadMoreIfAtEnd() tea.Cmd {
+	if !m.listHasNextPage || m.listLoadingMore || m.userEntryCursor < len(m.userEntries)-1 {
 		return nil
 	}
+	m.listLoadingMore = true
+	m.statusMsg = "Loading more..."
+	return m.fetchListPage(m.listPage + 1)
 }
 
 func getCurrentSeason(t time.Time) (string, int) {
@@ -182,27 +190,23 @@ func getCurrentSeason(t time.Time) (string, int) {
 	}
 }
 
-func makePublicRequest(query string, variables map[string]interface{}) (*AniListResponse, error) {
-	requestBody := map[string]interface{}{
-		"query":     query,
-		"variables": variables,
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := http.Post("https://graphql.anilist.co", "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
+// entryFromMedia wraps a browsed anime in a list entry, carrying over the
+// viewer's own list data when the query returned it
+func entryFromMedia(anime Anime) UserAnimeEntry {
+	entry := UserAnimeEntry{Media: anime}
+	if anime.MediaListEntry != nil {
+		entry.ID = anime.MediaListEntry.ID
+		entry.Status = anime.MediaListEntry.Status
+		entry.Progress = anime.MediaListEntry.Progress
+		entry.Score = anime.MediaListEntry.Score
 	}
-	defer resp.Body.Close()
+	return entry
+}
 
-	var result AniListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+func entriesFromMedia(media []Anime) []UserAnimeEntry {
+	var entries []UserAnimeEntry
+	for _, anime := range media {
+		entries = append(entries, entryFromMedia(anime))
 	}
-
-	return &result, nil
+	return entries
 }
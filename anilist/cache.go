@@ -0,0 +1,222 @@
+package anilist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// CachePolicy decides whether a cached response is used instead of asking AniList
+type CachePolicy int
+
+const (
+	// CacheFirst uses a fresh cached response, otherwise asks AniList and
+	// falls back to a stale response when AniList can't be reached
+	CacheFirst CachePolicy = iota
+	// CacheOnly never asks AniList and returns ErrNotCached on a miss
+	CacheOnly
+	// NetworkFirst always asks AniList and only falls back to the cache
+	// when AniList can't be reached
+	NetworkFirst
+)
+
+// maxCacheAge is how long a response is kept around as an offline fallback
+const maxCacheAge = 30 * 24 * time.Hour
+
+var (
+	responsesBucket = []byte("responses")
+	mutationsBucket = []byte("mutations")
+)
+
+// Cache stores query responses on disk, keyed by query, variables and token
+type Cache struct {
+	db *bolt.DB
+}
+
+type cacheEntry struct {
+	StoredAt time.Time       `json:"storedAt"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// OpenCache opens or creates the cache database at path and drops responses
+// older than maxCacheAge
+func OpenCache(path string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(mutationsBucket); err != nil {
+			return err
+		}
+		b, err := tx.CreateBucketIfNotExists(responsesBucket)
+		if err != nil {
+			return err
+		}
+
+		var expired [][]byte
+		err = b.ForEach(func(k, v []byte) error {
+			var entry cacheEntry
+			if json.Unmarshal(v, &entry) != nil || time.Since(entry.StoredAt) > maxCacheAge {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Cache{db: db}, nil
+}
+
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+func (c *Cache) get(key string) (cacheEntry, bool) {
+	var entry cacheEntry
+	found := false
+	c.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(responsesBucket).Get([]byte(key)); v != nil {
+			found = json.Unmarshal(v, &entry) == nil
+		}
+		return nil
+	})
+	return entry, found
+}
+
+func (c *Cache) put(key string, data json.RawMessage) error {
+	v, err := json.Marshal(cacheEntry{StoredAt: time.Now(), Data: data})
+	if err != nil {
+		return err
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(responsesBucket).Put([]byte(key), v)
+	})
+}
+
+// markMutated records that a token changed list data, so responses cached
+// for it before now are no longer fresh
+func (c *Cache) markMutated(scope string) error {
+	v, err := time.Now().MarshalText()
+	if err != nil {
+		return err
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(mutationsBucket).Put([]byte(scope), v)
+	})
+}
+
+// fresh reports whether entry is younger than ttl and newer than the last
+// mutation made with the token of scope
+func (c *Cache) fresh(scope string, entry cacheEntry, ttl time.Duration) bool {
+	if time.Since(entry.StoredAt) > ttl {
+		return false
+	}
+	var mutatedAt time.Time
+	c.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(mutationsBucket).Get([]byte(scope)); v != nil {
+			mutatedAt.UnmarshalText(v)
+		}
+		return nil
+	})
+	return entry.StoredAt.After(mutatedAt)
+}
+
+// cacheScope identifies the token without storing it, since responses such
+// as mediaListEntry depend on who is asking
+func (c *Client) cacheScope() string {
+	if c.token == "" {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(c.token))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (c *Client) cacheKey(query string, variables map[string]any) (string, error) {
+	vars, err := json.Marshal(variables)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(query))
+	h.Write([]byte{0})
+	h.Write(vars)
+	return c.cacheScope() + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cachedQuery runs a query through the cache according to the client's
+// policy. Fresh means younger than ttl.
+func (c *Client) cachedQuery(ctx context.Context, ttl time.Duration, query string, variables map[string]any, out any) error {
+	if c.cache == nil {
+		if c.policy == CacheOnly {
+			return ErrNotCached
+		}
+		return c.Query(ctx, query, variables, out)
+	}
+
+	key, err := c.cacheKey(query, variables)
+	if err != nil {
+		return err
+	}
+	entry, found := c.cache.get(key)
+
+	switch c.policy {
+	case CacheOnly:
+		if !found {
+			return ErrNotCached
+		}
+		return json.Unmarshal(entry.Data, out)
+	case CacheFirst:
+		if found && c.cache.fresh(c.cacheScope(), entry, ttl) {
+			return json.Unmarshal(entry.Data, out)
+		}
+	}
+
+	var data json.RawMessage
+	err = c.Query(ctx, query, variables, &data)
+	if err == nil {
+		c.cache.put(key, data)
+		return json.Unmarshal(data, out)
+	}
+
+	// AniList rejecting the query is an answer, anything else means it
+	// couldn't be reached and the stale copy is better than nothing
+	var apiErr *Error
+	if found && !errors.As(err, &apiErr) {
+		return json.Unmarshal(entry.Data, out)
+	}
+	return err
+}
+
+// mutate runs a mutation and marks the responses cached for the token stale
+func (c *Client) mutate(ctx context.Context, query string, variables map[string]any, out any) error {
+	if err := c.Query(ctx, query, variables, out); err != nil {
+		return err
+	}
+	if c.cache != nil {
+		c.cache.markMutated(c.cacheScope())
+	}
+	return nil
+}
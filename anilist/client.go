@@ -0,0 +1,238 @@
+package anilist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Endpoint is AniList's GraphQL API
+	Endpoint = "https://graphql.anilist.co"
+
+	// DefaultMaxRetries is how often a rate limited request is retried
+	DefaultMaxRetries = 3
+)
+
+// Client talks to the AniList GraphQL API. Clients derived with WithToken,
+// WithCache and WithCachePolicy share their rate limit and connectivity
+// state, so one Client per process is enough.
+type Client struct {
+	HTTPClient *http.Client
+	Endpoint   string
+	MaxRetries int
+
+	token   string
+	limiter *rateLimiter
+	cache   *Cache
+	policy  CachePolicy
+	offline *atomic.Bool
+}
+
+// NewClient creates an unauthenticated client
+func NewClient() *Client {
+	return &Client{
+		HTTPClient: http.DefaultClient,
+		Endpoint:   Endpoint,
+		MaxRetries: DefaultMaxRetries,
+		limiter:    &rateLimiter{remaining: -1},
+		offline:    new(atomic.Bool),
+	}
+}
+
+// WithToken returns a copy of the client that sends token as a bearer token.
+// An empty token gives an unauthenticated client.
+func (c *Client) WithToken(token string) *Client {
+	clone := *c
+	clone.token = token
+	return &clone
+}
+
+// WithCache returns a copy of the client that stores responses in cache.
+// A nil cache disables caching.
+func (c *Client) WithCache(cache *Cache) *Client {
+	clone := *c
+	clone.cache = cache
+	return &clone
+}
+
+// WithCachePolicy returns a copy of the client that uses cached responses
+// according to policy
+func (c *Client) WithCachePolicy(policy CachePolicy) *Client {
+	clone := *c
+	clone.policy = policy
+	return &clone
+}
+
+// Policy returns the cache policy the client uses
+func (c *Client) Policy() CachePolicy {
+	return c.policy
+}
+
+// Offline reports whether the last request failed to reach AniList
+func (c *Client) Offline() bool {
+	return c.offline.Load()
+}
+
+// Authenticated reports whether the client sends an access token
+func (c *Client) Authenticated() bool {
+	return c.token != ""
+}
+
+// Query runs a GraphQL query and decodes its data into out. Requests that are
+// rate limited are retried after the delay AniList asks for.
+func (c *Client) Query(ctx context.Context, query string, variables map[string]any, out any) error {
+	body, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx); err != nil {
+			return err
+		}
+
+		retryAfter, err := c.do(ctx, body, out)
+		if retryAfter == 0 {
+			return err
+		}
+		if attempt >= c.MaxRetries {
+			return &RateLimitError{RetryAfter: retryAfter}
+		}
+	}
+}
+
+// do sends one request. A non-zero duration means the request was rate limited
+// and may be retried after that long.
+func (c *Client) do(ctx context.Context, body []byte, out any) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		c.offline.Store(ctx.Err() == nil)
+		return 0, err
+	}
+	defer resp.Body.Close()
+	c.offline.Store(false)
+
+	c.limiter.update(resp)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+		c.limiter.block(retryAfter)
+		return retryAfter, nil
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+			Status  int    `json:"status"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		if resp.StatusCode >= 300 {
+			return 0, &Error{StatusCode: resp.StatusCode, Messages: []string{http.StatusText(resp.StatusCode)}}
+		}
+		return 0, fmt.Errorf("decoding AniList response: %w", err)
+	}
+
+	if len(envelope.Errors) > 0 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		for _, e := range envelope.Errors {
+			apiErr.Messages = append(apiErr.Messages, e.Message)
+		}
+		return 0, apiErr
+	}
+	if resp.StatusCode >= 300 {
+		return 0, &Error{StatusCode: resp.StatusCode, Messages: []string{http.StatusText(resp.StatusCode)}}
+	}
+
+	if out == nil || len(envelope.Data) == 0 {
+		return 0, nil
+	}
+	return 0, json.Unmarshal(envelope.Data, out)
+}
+
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || seconds <= 0 {
+		return time.Minute
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// rateLimiter follows AniList's X-RateLimit headers so that requests wait
+// for the window to reset instead of being rejected
+type rateLimiter struct {
+	mu           sync.Mutex
+	remaining    int
+	reset        time.Time
+	blockedUntil time.Time
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	until := l.blockedUntil
+	if l.remaining == 0 && l.reset.After(until) {
+		until = l.reset
+	}
+	l.mu.Unlock()
+
+	delay := time.Until(until)
+	if delay <= 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return &RateLimitError{RetryAfter: delay}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (l *rateLimiter) update(resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		l.remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		l.reset = time.Unix(reset, 0)
+	}
+}
+
+func (l *rateLimiter) block(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
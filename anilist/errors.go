@@ -0,0 +1,32 @@
+package anilist
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Error is returned when AniList answers with GraphQL errors or a failing
+// HTTP status
+type Error struct {
+	StatusCode int
+	Messages   []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("AniList error (%d): %s", e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+// RateLimitError is returned when a request is still rate limited after
+// retrying, or when waiting for the limit to reset would outlast the context
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("AniList rate limit reached, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// ErrNotCached is returned by clients using CacheOnly when a response isn't cached
+var ErrNotCached = errors.New("not in the AniList cache")
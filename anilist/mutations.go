@@ -0,0 +1,123 @@
+package anilist
+
+import (
+	"context"
+	"fmt"
+)
+
+// Fields of a MediaList returned by the list entry mutations and details
+const mediaListFields = `
+	id
+	status
+	progress
+	score
+	updatedAt
+	repeat
+	notes
+	private
+	hiddenFromStatusLists
+	startedAt {
+		year
+		month
+		day
+	}
+	completedAt {
+		year
+		month
+		day
+	}
+	customLists(asArray: true)
+`
+
+// SaveMediaListEntryInput holds the list fields written by SaveMediaListEntry.
+// The optional fields are left unchanged on AniList when nil.
+type SaveMediaListEntryInput struct {
+	MediaID  int
+	Status   string
+	Progress int
+	Score    float64
+
+	Repeat                *int
+	Notes                 *string
+	Private               *bool
+	HiddenFromStatusLists *bool
+	StartedAt             *FuzzyDate
+	CompletedAt           *FuzzyDate
+	CustomLists           []string // names of the custom lists the entry is on
+}
+
+// SaveMediaListEntry creates or updates the viewer's list entry for a media
+func (c *Client) SaveMediaListEntry(ctx context.Context, input SaveMediaListEntryInput) (*MediaList, error) {
+	query := `
+	mutation ($mediaId: Int, $status: MediaListStatus, $progress: Int, $score: Float,
+		$repeat: Int, $notes: String, $private: Boolean, $hiddenFromStatusLists: Boolean,
+		$startedAt: FuzzyDateInput, $completedAt: FuzzyDateInput, $customLists: [String]) {
+		SaveMediaListEntry(mediaId: $mediaId, status: $status, progress: $progress, score: $score,
+			repeat: $repeat, notes: $notes, private: $private, hiddenFromStatusLists: $hiddenFromStatusLists,
+			startedAt: $startedAt, completedAt: $completedAt, customLists: $customLists) {
+			` + mediaListFields + `
+		}
+	}
+	`
+
+	variables := map[string]any{
+		"mediaId":  input.MediaID,
+		"status":   input.Status,
+		"progress": input.Progress,
+		"score":    input.Score,
+	}
+	// Unset variables are left out so AniList keeps the current values
+	if input.Repeat != nil {
+		variables["repeat"] = *input.Repeat
+	}
+	if input.Notes != nil {
+		variables["notes"] = *input.Notes
+	}
+	if input.Private != nil {
+		variables["private"] = *input.Private
+	}
+	if input.HiddenFromStatusLists != nil {
+		variables["hiddenFromStatusLists"] = *input.HiddenFromStatusLists
+	}
+	if input.StartedAt != nil {
+		variables["startedAt"] = *input.StartedAt
+	}
+	if input.CompletedAt != nil {
+		variables["completedAt"] = *input.CompletedAt
+	}
+	if input.CustomLists != nil {
+		variables["customLists"] = input.CustomLists
+	}
+
+	var data struct {
+		SaveMediaListEntry MediaList `json:"SaveMediaListEntry"`
+	}
+	if err := c.mutate(ctx, query, variables, &data); err != nil {
+		return nil, err
+	}
+	return &data.SaveMediaListEntry, nil
+}
+
+// DeleteMediaListEntry removes a list entry by its ID
+func (c *Client) DeleteMediaListEntry(ctx context.Context, id int) error {
+	query := `
+	mutation ($id: Int) {
+		DeleteMediaListEntry(id: $id) {
+			deleted
+		}
+	}
+	`
+
+	var data struct {
+		DeleteMediaListEntry struct {
+			Deleted bool `json:"deleted"`
+		} `json:"DeleteMediaListEntry"`
+	}
+	if err := c.mutate(ctx, query, map[string]any{"id": id}, &data); err != nil {
+		return err
+	}
+	if !data.DeleteMediaListEntry.Deleted {
+		return fmt.Errorf("AniList did not delete list entry %d", id)
+	}
+	return nil
+}
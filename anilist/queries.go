@@ -0,0 +1,1145 @@
+package anilist
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Fields shared by every query that lists media
+const mediaFragment = `
+fragment media on Media {
+	id
+	idMal
+	title {
+		romaji
+		english
+		native
+		userPreferred
+	}
+	synonyms
+	format
+	status
+	episodes
+	averageScore
+	season
+	seasonYear
+	coverImage {
+		large
+	}
+	siteUrl
+	mediaListEntry {
+		id
+		status
+		progress
+		score
+	}
+}
+`
+
+// Upper bound on pages fetched by methods that collect every page
+const maxPages = 10
+
+// How long cached responses are used before asking AniList again
+const (
+	listTTL   = 5 * time.Minute
+	browseTTL = time.Hour
+	mediaTTL  = 24 * time.Hour
+)
+
+// Viewer returns the user the client's token belongs to
+func (c *Client) Viewer(ctx context.Context) (*Viewer, error) {
+	query := `
+	query {
+		Viewer {
+			id
+			name
+			options {
+				titleLanguage
+			}
+			mediaListOptions {
+				scoreFormat
+			}
+		}
+	}
+	`
+
+	var data struct {
+		Viewer Viewer `json:"Viewer"`
+	}
+	if err := c.cachedQuery(ctx, mediaTTL, query, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data.Viewer, nil
+}
+
+// Trending returns a page of anime sorted by current trend
+func (c *Client) Trending(ctx context.Context, page, perPage int) (*MediaPage, error) {
+	return c.browse(ctx, "TRENDING_DESC", nil, page, perPage)
+}
+
+// PopularThisSeason returns a page of the most popular anime of a season
+func (c *Client) PopularThisSeason(ctx context.Context, season string, year, page, perPage int) (*MediaPage, error) {
+	return c.browse(ctx, "POPULARITY_DESC", map[string]any{
+		"season": season,
+		"year":   year,
+	}, page, perPage)
+}
+
+// TopRated returns a page of anime sorted by average score
+func (c *Client) TopRated(ctx context.Context, page, perPage int) (*MediaPage, error) {
+	return c.browse(ctx, "SCORE_DESC", nil, page, perPage)
+}
+
+func (c *Client) browse(ctx context.Context, sort string, variables map[string]any, page, perPage int) (*MediaPage, error) {
+	query := `
+	query ($sort: [MediaSort], $season: MediaSeason, $year: Int, $page: Int, $perPage: Int) {
+		Page(page: $page, perPage: $perPage) {
+			pageInfo {
+				total
+				perPage
+				currentPage
+				lastPage
+				hasNextPage
+			}
+			media(type: ANIME, season: $season, seasonYear: $year, sort: $sort) {
+				...media
+			}
+		}
+	}
+	` + mediaFragment
+
+	if variables == nil {
+		variables = map[string]any{}
+	}
+	variables["sort"] = []string{sort}
+	variables["page"] = page
+	variables["perPage"] = perPage
+
+	var data struct {
+		Page MediaPage `json:"Page"`
+	}
+	if err := c.cachedQuery(ctx, browseTTL, query, variables, &data); err != nil {
+		return nil, err
+	}
+	return &data.Page, nil
+}
+
+// MediaFilter narrows a media search. Zero values leave an argument unset.
+type MediaFilter struct {
+	Search   string
+	Genres   []string
+	Tags     []string
+	YearFrom int
+	YearTo   int
+	Season   string
+	Formats  []string
+	Status   string
+	MinScore int
+	Sort     string
+}
+
+// IsZero reports whether the filter sets nothing besides the sort order
+func (f MediaFilter) IsZero() bool {
+	return f.Search == "" && len(f.Genres) == 0 && len(f.Tags) == 0 &&
+		f.YearFrom == 0 && f.YearTo == 0 && f.Season == "" &&
+		len(f.Formats) == 0 && f.Status == "" && f.MinScore == 0
+}
+
+func (f MediaFilter) variables() map[string]any {
+	variables := map[string]any{}
+	if f.Search != "" {
+		variables["search"] = f.Search
+	}
+	if len(f.Genres) > 0 {
+		variables["genres"] = f.Genres
+	}
+	if len(f.Tags) > 0 {
+		variables["tags"] = f.Tags
+	}
+	// A single year matches the season year, a range matches the start date
+	if f.YearFrom != 0 && f.YearFrom == f.YearTo {
+		variables["seasonYear"] = f.YearFrom
+	} else {
+		if f.YearFrom != 0 {
+			variables["startAfter"] = f.YearFrom*10000 - 1
+		}
+		if f.YearTo != 0 {
+			// Year-only start dates are stored as YYYY0000
+			variables["startBefore"] = (f.YearTo + 1) * 10000
+		}
+	}
+	if f.Season != "" {
+		variables["season"] = f.Season
+	}
+	if len(f.Formats) > 0 {
+		variables["formats"] = f.Formats
+	}
+	if f.Status != "" {
+		variables["status"] = f.Status
+	}
+	if f.MinScore > 0 {
+		variables["minScore"] = f.MinScore
+	}
+	// AniList rejects sorting by search match without a search
+	sort := f.Sort
+	if sort == "" || (sort == "SEARCH_MATCH" && f.Search == "") {
+		sort = "POPULARITY_DESC"
+	}
+	variables["sort"] = []string{sort}
+	return variables
+}
+
+// SearchMedia returns a page of anime matching filter
+func (c *Client) SearchMedia(ctx context.Context, filter MediaFilter, page, perPage int) (*MediaPage, error) {
+	query := `
+	query (
+		$search: String, $genres: [String], $tags: [String], $seasonYear: Int,
+		$startAfter: FuzzyDateInt, $startBefore: FuzzyDateInt, $season: MediaSeason,
+		$formats: [MediaFormat], $status: MediaStatus, $minScore: Int, $sort: [MediaSort],
+		$page: Int, $perPage: Int
+	) {
+		Page(page: $page, perPage: $perPage) {
+			pageInfo {
+				total
+				perPage
+				currentPage
+				lastPage
+				hasNextPage
+			}
+			media(
+				type: ANIME, search: $search, genre_in: $genres, tag_in: $tags,
+				seasonYear: $seasonYear, startDate_greater: $startAfter, startDate_lesser: $startBefore,
+				season: $season, format_in: $formats, status: $status,
+				averageScore_greater: $minScore, sort: $sort
+			) {
+				...media
+			}
+		}
+	}
+	` + mediaFragment
+
+	variables := filter.variables()
+	variables["page"] = page
+	variables["perPage"] = perPage
+
+	var data struct {
+		Page MediaPage `json:"Page"`
+	}
+	if err := c.cachedQuery(ctx, browseTTL, query, variables, &data); err != nil {
+		return nil, err
+	}
+	return &data.Page, nil
+}
+
+// Media returns the full record of one anime
+func (c *Client) Media(ctx context.Context, id int) (*MediaDetail, error) {
+	query := `
+	query ($id: Int) {
+		Media(id: $id, type: ANIME) {
+			...media
+			title {
+				romaji
+				english
+				native
+			}
+			description(asHtml: false)
+			genres
+			tags {
+				name
+				rank
+				isMediaSpoiler
+			}
+			studios {
+				nodes {
+					name
+					isAnimationStudio
+				}
+			}
+			source
+			duration
+			startDate {
+				year
+				month
+				day
+			}
+			endDate {
+				year
+				month
+				day
+			}
+			nextAiringEpisode {
+				airingAt
+				timeUntilAiring
+				episode
+			}
+			relations {
+				edges {
+					relationType
+					node {
+						id
+						type
+						title {
+							romaji
+							english
+							native
+						}
+						format
+						status
+						episodes
+					}
+				}
+			}
+			externalLinks {
+				site
+				url
+			}
+		}
+	}
+	` + mediaFragment
+
+	var data struct {
+		Media *MediaDetail `json:"Media"`
+	}
+	if err := c.cachedQuery(ctx, mediaTTL, query, map[string]any{"id": id}, &data); err != nil {
+		return nil, err
+	}
+	if data.Media == nil {
+		return nil, fmt.Errorf("anime %d not found", id)
+	}
+	return data.Media, nil
+}
+
+// Recommendations returns the anime recommended for a media, best rated first.
+// Recommendations of deleted media are left out.
+func (c *Client) Recommendations(ctx context.Context, mediaID, perPage int) ([]Recommendation, error) {
+	query := `
+	query ($id: Int, $perPage: Int) {
+		Media(id: $id, type: ANIME) {
+			recommendations(perPage: $perPage, sort: [RATING_DESC, ID]) {
+				nodes {
+					rating
+					mediaRecommendation {
+						...media
+					}
+				}
+			}
+		}
+	}
+	` + mediaFragment
+
+	var data struct {
+		Media *struct {
+			Recommendations struct {
+				Nodes []Recommendation `json:"nodes"`
+			} `json:"recommendations"`
+		} `json:"Media"`
+	}
+	if err := c.cachedQuery(ctx, browseTTL, query, map[string]any{"id": mediaID, "perPage": perPage}, &data); err != nil {
+		return nil, err
+	}
+	if data.Media == nil {
+		return nil, fmt.Errorf("anime %d not found", mediaID)
+	}
+
+	var recs []Recommendation
+	for _, r := range data.Media.Recommendations.Nodes {
+		if r.Media != nil {
+			recs = append(recs, r)
+		}
+	}
+	return recs, nil
+}
+
+// Relation types followed when collecting a franchise. CHARACTER and OTHER
+// often lead to unrelated shows, ADAPTATION and SOURCE to manga.
+var franchiseRelations = map[string]bool{
+	"PREQUEL":     true,
+	"SEQUEL":      true,
+	"PARENT":      true,
+	"SIDE_STORY":  true,
+	"SPIN_OFF":    true,
+	"ALTERNATIVE": true,
+	"SUMMARY":     true,
+	"COMPILATION": true,
+	"CONTAINS":    true,
+}
+
+// Upper bound on the anime collected for one franchise
+const maxFranchiseSize = 80
+
+// Franchise collects every anime connected to mediaID through franchise
+// relations, keyed by ID. Each round fetches the newly discovered anime in
+// one request.
+func (c *Client) Franchise(ctx context.Context, mediaID int) (map[int]*FranchiseNode, error) {
+	query := `
+	query ($ids: [Int], $page: Int) {
+		Page(page: $page, perPage: 50) {
+			pageInfo {
+				hasNextPage
+			}
+			media(id_in: $ids, type: ANIME) {
+				...media
+				startDate {
+					year
+					month
+					day
+				}
+				relations {
+					edges {
+						relationType
+						node {
+							id
+							type
+						}
+					}
+				}
+			}
+		}
+	}
+	` + mediaFragment
+
+	type node struct {
+		FranchiseNode
+		RelationEdges struct {
+			Edges []struct {
+				RelationType string `json:"relationType"`
+				Node         struct {
+					ID   int    `json:"id"`
+					Type string `json:"type"`
+				} `json:"node"`
+			} `json:"edges"`
+		} `json:"relations"`
+	}
+
+	nodes := map[int]*FranchiseNode{}
+	queued := map[int]bool{mediaID: true}
+	frontier := []int{mediaID}
+	for len(frontier) > 0 && len(nodes) < maxFranchiseSize {
+		var next []int
+		for page := 1; page <= maxPages; page++ {
+			var data struct {
+				Page struct {
+					PageInfo PageInfo `json:"pageInfo"`
+					Media    []node   `json:"media"`
+				} `json:"Page"`
+			}
+			variables := map[string]any{"ids": frontier, "page": page}
+			if err := c.cachedQuery(ctx, browseTTL, query, variables, &data); err != nil {
+				return nil, err
+			}
+
+			for _, n := range data.Page.Media {
+				franchiseNode := n.FranchiseNode
+				for _, e := range n.RelationEdges.Edges {
+					if e.Node.Type != "ANIME" || !franchiseRelations[e.RelationType] {
+						continue
+					}
+					franchiseNode.Relations = append(franchiseNode.Relations, FranchiseRelation{Type: e.RelationType, MediaID: e.Node.ID})
+					if !queued[e.Node.ID] {
+						queued[e.Node.ID] = true
+						next = append(next, e.Node.ID)
+					}
+				}
+				nodes[franchiseNode.ID] = &franchiseNode
+			}
+			if !data.Page.PageInfo.HasNextPage {
+				break
+			}
+		}
+		if room := maxFranchiseSize - len(nodes); len(next) > room {
+			next = next[:max(room, 0)]
+		}
+		frontier = next
+	}
+
+	if nodes[mediaID] == nil {
+		return nil, fmt.Errorf("anime %d not found", mediaID)
+	}
+	// Relations may point at anime beyond the size limit
+	for _, n := range nodes {
+		kept := n.Relations[:0]
+		for _, r := range n.Relations {
+			if nodes[r.MediaID] != nil {
+				kept = append(kept, r)
+			}
+		}
+		n.Relations = kept
+	}
+	return nodes, nil
+}
+
+// MediaListCollection returns a user's anime lists, optionally limited to
+// one status. An empty status returns every list.
+func (c *Client) MediaListCollection(ctx context.Context, userID int, status string) ([]MediaListGroup, error) {
+	query := `
+	query ($userId: Int, $status: MediaListStatus) {
+		MediaListCollection(userId: $userId, type: ANIME, status: $status, sort: UPDATED_TIME_DESC) {
+			lists {
+				name
+				status
+				isCustomList
+				entries {
+					id
+					status
+					progress
+					score
+					updatedAt
+					media {
+						...media
+					}
+				}
+			}
+		}
+	}
+	` + mediaFragment
+
+	variables := map[string]any{
+		"userId": userID,
+	}
+	if status != "" {
+		variables["status"] = status
+	}
+
+	var data struct {
+		MediaListCollection struct {
+			Lists []MediaListGroup `json:"lists"`
+		} `json:"MediaListCollection"`
+	}
+	if err := c.cachedQuery(ctx, listTTL, query, variables, &data); err != nil {
+		return nil, err
+	}
+	return data.MediaListCollection.Lists, nil
+}
+
+// MediaListCollectionDetails returns every entry of a user's anime lists
+// with all its fields, as needed for a full export. Entries on several lists
+// are returned once.
+func (c *Client) MediaListCollectionDetails(ctx context.Context, userID int) ([]MediaList, error) {
+	query := `
+	query ($userId: Int) {
+		MediaListCollection(userId: $userId, type: ANIME, sort: MEDIA_ID) {
+			lists {
+				entries {
+					` + mediaListFields + `
+					media {
+						...media
+					}
+				}
+			}
+		}
+	}
+	` + mediaFragment
+
+	var data struct {
+		MediaListCollection struct {
+			Lists []MediaListGroup `json:"lists"`
+		} `json:"MediaListCollection"`
+	}
+	if err := c.cachedQuery(ctx, listTTL, query, map[string]any{"userId": userID}, &data); err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{}
+	var entries []MediaList
+	for _, list := range data.MediaListCollection.Lists {
+		for _, entry := range list.Entries {
+			if !seen[entry.ID] {
+				seen[entry.ID] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// MediaByMalIDs returns the anime with the given MyAnimeList IDs. IDs
+// AniList doesn't know are left out.
+func (c *Client) MediaByMalIDs(ctx context.Context, malIDs []int) ([]Media, error) {
+	query := `
+	query ($ids: [Int], $page: Int) {
+		Page(page: $page, perPage: 50) {
+			pageInfo {
+				hasNextPage
+			}
+			media(idMal_in: $ids, type: ANIME) {
+				...media
+			}
+		}
+	}
+	` + mediaFragment
+
+	var media []Media
+	for start := 0; start < len(malIDs); start += 50 {
+		ids := malIDs[start:min(start+50, len(malIDs))]
+		for page := 1; page <= maxPages; page++ {
+			variables := map[string]any{
+				"ids":  ids,
+				"page": page,
+			}
+
+			var data struct {
+				Page MediaPage `json:"Page"`
+			}
+			if err := c.cachedQuery(ctx, mediaTTL, query, variables, &data); err != nil {
+				return nil, err
+			}
+
+			media = append(media, data.Page.Media...)
+			if !data.Page.PageInfo.HasNextPage {
+				break
+			}
+		}
+	}
+	return media, nil
+}
+
+// MediaListIDs returns the media IDs on a user's list with any of the given
+// statuses, each once
+func (c *Client) MediaListIDs(ctx context.Context, userID int, statuses ...string) ([]int, error) {
+	query := `
+	query ($userId: Int, $statuses: [MediaListStatus]) {
+		MediaListCollection(userId: $userId, type: ANIME, status_in: $statuses) {
+			lists {
+				entries {
+					mediaId
+				}
+			}
+		}
+	}
+	`
+
+	variables := map[string]any{
+		"userId":   userID,
+		"statuses": statuses,
+	}
+
+	var data struct {
+		MediaListCollection struct {
+			Lists []struct {
+				Entries []struct {
+					MediaID int `json:"mediaId"`
+				} `json:"entries"`
+			} `json:"lists"`
+		} `json:"MediaListCollection"`
+	}
+	if err := c.cachedQuery(ctx, listTTL, query, variables, &data); err != nil {
+		return nil, err
+	}
+
+	// Entries on custom lists show up once more per list
+	var ids []int
+	seen := map[int]bool{}
+	for _, list := range data.MediaListCollection.Lists {
+		for _, entry := range list.Entries {
+			if !seen[entry.MediaID] {
+				seen[entry.MediaID] = true
+				ids = append(ids, entry.MediaID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// SeasonMediaIDs returns the IDs of every anime of a season
+func (c *Client) SeasonMediaIDs(ctx context.Context, season string, year int) ([]int, error) {
+	query := `
+	query ($season: MediaSeason, $year: Int, $page: Int) {
+		Page(page: $page, perPage: 50) {
+			pageInfo {
+				hasNextPage
+			}
+			media(type: ANIME, season: $season, seasonYear: $year, sort: POPULARITY_DESC) {
+				id
+			}
+		}
+	}
+	`
+
+	var ids []int
+	for page := 1; page <= maxPages; page++ {
+		variables := map[string]any{
+			"season": season,
+			"year":   year,
+			"page":   page,
+		}
+
+		var data struct {
+			Page MediaPage `json:"Page"`
+		}
+		if err := c.cachedQuery(ctx, browseTTL, query, variables, &data); err != nil {
+			return nil, err
+		}
+
+		for _, media := range data.Page.Media {
+			ids = append(ids, media.ID)
+		}
+		if !data.Page.PageInfo.HasNextPage {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// AiringSchedules returns the episodes of the given anime airing between
+// from and to, in airing order
+func (c *Client) AiringSchedules(ctx context.Context, mediaIDs []int, from, to time.Time) ([]AiringSchedule, error) {
+	query := `
+	query ($ids: [Int], $from: Int, $to: Int, $page: Int) {
+		Page(page: $page, perPage: 50) {
+			pageInfo {
+				hasNextPage
+			}
+			airingSchedules(mediaId_in: $ids, airingAt_greater: $from, airingAt_lesser: $to, sort: TIME) {
+				id
+				airingAt
+				timeUntilAiring
+				episode
+				media {
+					...media
+				}
+			}
+		}
+	}
+	` + mediaFragment
+
+	var schedules []AiringSchedule
+	for page := 1; page <= maxPages; page++ {
+		variables := map[string]any{
+			"ids":  mediaIDs,
+			"from": from.Unix(),
+			"to":   to.Unix(),
+			"page": page,
+		}
+
+		var data struct {
+			Page struct {
+				PageInfo        PageInfo         `json:"pageInfo"`
+				AiringSchedules []AiringSchedule `json:"airingSchedules"`
+			} `json:"Page"`
+		}
+		if err := c.cachedQuery(ctx, browseTTL, query, variables, &data); err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, data.Page.AiringSchedules...)
+		if !data.Page.PageInfo.HasNextPage {
+			break
+		}
+	}
+	return schedules, nil
+}
+
+// FollowingActivities returns a page of the anime list updates of the users
+// the viewer follows, newest first. Updates of deleted media are left out.
+func (c *Client) FollowingActivities(ctx context.Context, page, perPage int) (*ActivityPage, error) {
+	query := `
+	query ($page: Int, $perPage: Int) {
+		Page(page: $page, perPage: $perPage) {
+			pageInfo {
+				hasNextPage
+			}
+			activities(isFollowing: true, type: ANIME_LIST, sort: ID_DESC) {
+				... on ListActivity {
+					id
+					status
+					progress
+					createdAt
+					user {
+						id
+						name
+					}
+					media {
+						...media
+					}
+				}
+			}
+		}
+	}
+	` + mediaFragment
+
+	variables := map[string]any{
+		"page":    page,
+		"perPage": perPage,
+	}
+
+	var data struct {
+		Page ActivityPage `json:"Page"`
+	}
+	if err := c.cachedQuery(ctx, listTTL, query, variables, &data); err != nil {
+		return nil, err
+	}
+
+	activities := data.Page.Activities[:0]
+	for _, a := range data.Page.Activities {
+		if a.Media != nil {
+			activities = append(activities, a)
+		}
+	}
+	data.Page.Activities = activities
+	return &data.Page, nil
+}
+
+// Following returns the users a user follows
+func (c *Client) Following(ctx context.Context, userID int) ([]User, error) {
+	query := `
+	query ($userId: Int!, $page: Int) {
+		Page(page: $page, perPage: 50) {
+			pageInfo {
+				hasNextPage
+			}
+			following(userId: $userId, sort: USERNAME) {
+				id
+				name
+			}
+		}
+	}
+	`
+
+	var users []User
+	for page := 1; page <= maxPages; page++ {
+		var data struct {
+			Page struct {
+				PageInfo  PageInfo `json:"pageInfo"`
+				Following []User   `json:"following"`
+			} `json:"Page"`
+		}
+		variables := map[string]any{"userId": userID, "page": page}
+		if err := c.cachedQuery(ctx, listTTL, query, variables, &data); err != nil {
+			return nil, err
+		}
+
+		users = append(users, data.Page.Following...)
+		if !data.Page.PageInfo.HasNextPage {
+			break
+		}
+	}
+	return users, nil
+}
+
+// MediaListsOf returns the list entries the given users have for an anime,
+// with scores in scoreFormat. Users without an entry are left out.
+func (c *Client) MediaListsOf(ctx context.Context, mediaID int, userIDs []int, scoreFormat string) ([]UserMediaList, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+	query ($mediaId: Int, $userIds: [Int], $format: ScoreFormat, $page: Int) {
+		Page(page: $page, perPage: 50) {
+			pageInfo {
+				hasNextPage
+			}
+			mediaList(mediaId: $mediaId, userId_in: $userIds, type: ANIME) {
+				status
+				progress
+				score(format: $format)
+				user {
+					id
+					name
+				}
+			}
+		}
+	}
+	`
+
+	var lists []UserMediaList
+	for page := 1; page <= maxPages; page++ {
+		var data struct {
+			Page struct {
+				PageInfo  PageInfo        `json:"pageInfo"`
+				MediaList []UserMediaList `json:"mediaList"`
+			} `json:"Page"`
+		}
+		variables := map[string]any{
+			"mediaId": mediaID,
+			"userIds": userIDs,
+			"format":  scoreFormat,
+			"page":    page,
+		}
+		if err := c.cachedQuery(ctx, listTTL, query, variables, &data); err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, data.Page.MediaList...)
+		if !data.Page.PageInfo.HasNextPage {
+			break
+		}
+	}
+	return lists, nil
+}
+
+// Entries kept of the genre and studio statistics
+const statisticsLimit = 10
+
+// AnimeStatistics returns a user's anime statistics. Scores and release years
+// are sorted ascending, genres, formats and studios by count.
+func (c *Client) AnimeStatistics(ctx context.Context, userID int) (*AnimeStatistics, error) {
+	query := `
+	query ($userId: Int, $limit: Int) {
+		User(id: $userId) {
+			statistics {
+				anime {
+					count
+					meanScore
+					standardDeviation
+					minutesWatched
+					episodesWatched
+					scores(sort: MEAN_SCORE) {
+						score
+						count
+					}
+					genres(limit: $limit, sort: COUNT_DESC) {
+						genre
+						count
+						meanScore
+						minutesWatched
+					}
+					formats(sort: COUNT_DESC) {
+						format
+						count
+					}
+					releaseYears(sort: ID) {
+						releaseYear
+						count
+					}
+					studios(limit: $limit, sort: COUNT_DESC) {
+						studio {
+							name
+						}
+						count
+						meanScore
+					}
+				}
+			}
+		}
+	}
+	`
+
+	var data struct {
+		User *struct {
+			Statistics struct {
+				Anime AnimeStatistics `json:"anime"`
+			} `json:"statistics"`
+		} `json:"User"`
+	}
+	variables := map[string]any{"userId": userID, "limit": statisticsLimit}
+	if err := c.cachedQuery(ctx, browseTTL, query, variables, &data); err != nil {
+		return nil, err
+	}
+	if data.User == nil {
+		return nil, fmt.Errorf("user %d not found", userID)
+	}
+
+	stats := &data.User.Statistics.Anime
+	sort.Slice(stats.Scores, func(i, j int) bool { return stats.Scores[i].Score < stats.Scores[j].Score })
+	sort.Slice(stats.ReleaseYears, func(i, j int) bool {
+		return stats.ReleaseYears[i].ReleaseYear < stats.ReleaseYears[j].ReleaseYear
+	})
+	return stats, nil
+}
+
+// Notification types shown in the inbox
+var notificationTypes = []string{
+	"AIRING",
+	"RELATED_MEDIA_ADDITION",
+	"FOLLOWING",
+	"ACTIVITY_MESSAGE",
+	"ACTIVITY_REPLY",
+	"ACTIVITY_MENTION",
+	"ACTIVITY_LIKE",
+	"ACTIVITY_REPLY_LIKE",
+	"ACTIVITY_REPLY_SUBSCRIBED",
+}
+
+// UnreadNotificationCount returns the number of unread notifications of the
+// viewer. It is always asked for, the cache only stands in offline.
+func (c *Client) UnreadNotificationCount(ctx context.Context) (int, error) {
+	query := `
+	query {
+		Viewer {
+			unreadNotificationCount
+		}
+	}
+	`
+
+	var data struct {
+		Viewer struct {
+			UnreadNotificationCount int `json:"unreadNotificationCount"`
+		} `json:"Viewer"`
+	}
+	if err := c.cachedQuery(ctx, 0, query, nil, &data); err != nil {
+		return 0, err
+	}
+	return data.Viewer.UnreadNotificationCount, nil
+}
+
+// Notifications returns a page of the viewer's notifications, newest first.
+// With markRead AniList resets the unread count.
+func (c *Client) Notifications(ctx context.Context, page, perPage int, markRead bool) (*NotificationPage, error) {
+	query := `
+	query ($page: Int, $perPage: Int, $types: [NotificationType], $markRead: Boolean) {
+		Page(page: $page, perPage: $perPage) {
+			pageInfo {
+				hasNextPage
+			}
+			notifications(type_in: $types, resetNotificationCount: $markRead) {
+				... on AiringNotification {
+					id
+					type
+					createdAt
+					episode
+					contexts
+					media {
+						...media
+					}
+				}
+				... on RelatedMediaAdditionNotification {
+					id
+					type
+					createdAt
+					context
+					media {
+						...media
+					}
+				}
+				... on FollowingNotification {
+					id
+					type
+					createdAt
+					context
+					user {
+						id
+						name
+					}
+				}
+				... on ActivityMessageNotification {
+					id
+					type
+					createdAt
+					context
+					activityId
+					user {
+						id
+						name
+					}
+				}
+				... on ActivityReplyNotification {
+					id
+					type
+					createdAt
+					context
+					activityId
+					user {
+						id
+						name
+					}
+				}
+				... on ActivityMentionNotification {
+					id
+					type
+					createdAt
+					context
+					activityId
+					user {
+						id
+						name
+					}
+				}
+				... on ActivityLikeNotification {
+					id
+					type
+					createdAt
+					context
+					activityId
+					user {
+						id
+						name
+					}
+				}
+				... on ActivityReplyLikeNotification {
+					id
+					type
+					createdAt
+					context
+					activityId
+					user {
+						id
+						name
+					}
+				}
+				... on ActivityReplySubscribedNotification {
+					id
+					type
+					createdAt
+					context
+					activityId
+					user {
+						id
+						name
+					}
+				}
+			}
+		}
+	}
+	` + mediaFragment
+
+	variables := map[string]any{
+		"page":     page,
+		"perPage":  perPage,
+		"types":    notificationTypes,
+		"markRead": markRead,
+	}
+
+	// Never served from the cache while online, reading has to reach AniList
+	// to reset the unread count
+	var data struct {
+		Page NotificationPage `json:"Page"`
+	}
+	if err := c.cachedQuery(ctx, 0, query, variables, &data); err != nil {
+		return nil, err
+	}
+	return &data.Page, nil
+}
+
+// MediaListDetails returns the viewer's full list entry for a media, nil when
+// it isn't on their list, along with the names of their custom anime lists
+func (c *Client) MediaListDetails(ctx context.Context, mediaID int) (*MediaList, []string, error) {
+	query := `
+	query ($id: Int) {
+		Viewer {
+			mediaListOptions {
+				animeList {
+					customLists
+				}
+			}
+		}
+		Media(id: $id, type: ANIME) {
+			mediaListEntry {
+				` + mediaListFields + `
+			}
+		}
+	}
+	`
+
+	var data struct {
+		Viewer struct {
+			MediaListOptions struct {
+				AnimeList struct {
+					CustomLists []string `json:"customLists"`
+				} `json:"animeList"`
+			} `json:"mediaListOptions"`
+		} `json:"Viewer"`
+		Media *struct {
+			MediaListEntry *MediaList `json:"mediaListEntry"`
+		} `json:"Media"`
+	}
+	if err := c.cachedQuery(ctx, listTTL, query, map[string]any{"id": mediaID}, &data); err != nil {
+		return nil, nil, err
+	}
+	if data.Media == nil {
+		return nil, nil, fmt.Errorf("anime %d not found", mediaID)
+	}
+	return data.Media.MediaListEntry, data.Viewer.MediaListOptions.AnimeList.CustomLists, nil
+}
@@ -0,0 +1,85 @@
+package anilist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMediaFilterVariables(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter MediaFilter
+		want   map[string]any
+	}{
+		{
+			name:   "default sort",
+			filter: MediaFilter{},
+			want:   map[string]any{"sort": []string{"POPULARITY_DESC"}},
+		},
+		{
+			name:   "single year",
+			filter: MediaFilter{YearFrom: 2020, YearTo: 2020},
+			want:   map[string]any{"seasonYear": 2020, "sort": []string{"POPULARITY_DESC"}},
+		},
+		{
+			name:   "year range excludes year-only dates after it",
+			filter: MediaFilter{YearFrom: 2010, YearTo: 2019},
+			want:   map[string]any{"startAfter": 20099999, "startBefore": 20200000, "sort": []string{"POPULARITY_DESC"}},
+		},
+		{
+			name:   "search match with a search",
+			filter: MediaFilter{Search: "frieren", Sort: "SEARCH_MATCH"},
+			want:   map[string]any{"search": "frieren", "sort": []string{"SEARCH_MATCH"}},
+		},
+		{
+			name:   "search match without a search",
+			filter: MediaFilter{Genres: []string{"Drama"}, Sort: "SEARCH_MATCH"},
+			want:   map[string]any{"genres": []string{"Drama"}, "sort": []string{"POPULARITY_DESC"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.variables(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaListIDs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		if got := fmt.Sprint(req.Variables["statuses"]); got != "[CURRENT REPEATING]" {
+			t.Errorf("statuses = %s", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":{"MediaListCollection":{"lists":[
+			{"entries":[{"mediaId":1},{"mediaId":2}]},
+			{"entries":[{"mediaId":3}]},
+			{"entries":[{"mediaId":2}]}
+		]}}}`)
+	}))
+	defer server.Close()
+
+	c := NewClient()
+	c.HTTPClient = server.Client()
+	c.Endpoint = server.URL
+	ids, err := c.MediaListIDs(context.Background(), 7, "CURRENT", "REPEATING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+		t.Errorf("got %v, want [1 2 3]", ids)
+	}
+}
@@ -0,0 +1,310 @@
+package anilist
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Media is an anime as returned by list, search and browse queries
+type Media struct {
+	ID          int      `json:"id"`
+	IDMal       int      `json:"idMal"` // 0 when the anime isn't on MyAnimeList
+	Title       Title    `json:"title"`
+	Synonyms    []string `json:"synonyms"`
+	Type        string   `json:"type"`
+	Format      string   `json:"format"`
+	Status      string   `json:"status"`
+	Episodes    *int     `json:"episodes"`
+	Score       *int     `json:"averageScore"`
+	Season      string   `json:"season"`
+	SeasonYear  *int     `json:"seasonYear"`
+	Description string   `json:"description"`
+	CoverImage  struct {
+		Large string `json:"large"`
+	} `json:"coverImage"`
+	SiteURL        string          `json:"siteUrl"`
+	MediaListEntry *MediaListEntry `json:"mediaListEntry"`
+}
+
+type Title struct {
+	Romaji        string `json:"romaji"`
+	English       string `json:"english"`
+	Native        string `json:"native"`
+	UserPreferred string `json:"userPreferred"` // in the viewer's title language, romaji without login
+}
+
+// Preferred returns the title in an AniList title language like ENGLISH or
+// NATIVE_STYLISED, falling back to romaji. Without a language English is
+// preferred.
+func (t Title) Preferred(language string) string {
+	switch strings.TrimSuffix(language, "_STYLISED") {
+	case "", "ENGLISH":
+		if t.English != "" {
+			return t.English
+		}
+	case "NATIVE":
+		if t.Native != "" {
+			return t.Native
+		}
+	}
+	if t.Romaji != "" {
+		return t.Romaji
+	}
+	return t.English
+}
+
+// MediaListEntry is the viewer's list entry as embedded in a Media query
+type MediaListEntry struct {
+	ID       int     `json:"id"`
+	Status   string  `json:"status"`
+	Progress int     `json:"progress"`
+	Score    float64 `json:"score"`
+}
+
+// MediaList is an entry on a user's anime list. List queries only fill the
+// fields up to UpdatedAt, the rest is loaded with MediaListDetails.
+type MediaList struct {
+	ID        int     `json:"id"`
+	Status    string  `json:"status"`
+	Progress  int     `json:"progress"`
+	Score     float64 `json:"score"`
+	Media     Media   `json:"media"`
+	UpdatedAt int64   `json:"updatedAt"`
+
+	Repeat                int                    `json:"repeat"`
+	Notes                 string                 `json:"notes"`
+	Private               bool                   `json:"private"`
+	HiddenFromStatusLists bool                   `json:"hiddenFromStatusLists"`
+	StartedAt             FuzzyDate              `json:"startedAt"`
+	CompletedAt           FuzzyDate              `json:"completedAt"`
+	CustomLists           []CustomListMembership `json:"customLists"`
+}
+
+// CustomListMembership tells whether an entry is on one of the user's
+// custom lists
+type CustomListMembership struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// MediaListGroup is one named list of a MediaListCollection. Status lists
+// carry their status, custom lists are flagged with IsCustomList.
+type MediaListGroup struct {
+	Name         string      `json:"name"`
+	Status       string      `json:"status"`
+	IsCustomList bool        `json:"isCustomList"`
+	Entries      []MediaList `json:"entries"`
+}
+
+// MediaDetail is the full Media record shown on the detail screen
+type MediaDetail struct {
+	Media
+	Genres []string `json:"genres"`
+	Tags   []struct {
+		Name           string `json:"name"`
+		Rank           int    `json:"rank"`
+		IsMediaSpoiler bool   `json:"isMediaSpoiler"`
+	} `json:"tags"`
+	Studios struct {
+		Nodes []struct {
+			Name              string `json:"name"`
+			IsAnimationStudio bool   `json:"isAnimationStudio"`
+		} `json:"nodes"`
+	} `json:"studios"`
+	Source            string             `json:"source"`
+	Duration          *int               `json:"duration"`
+	StartDate         FuzzyDate          `json:"startDate"`
+	EndDate           FuzzyDate          `json:"endDate"`
+	NextAiringEpisode *NextAiringEpisode `json:"nextAiringEpisode"`
+	Relations         struct {
+		Edges []struct {
+			RelationType string `json:"relationType"`
+			Node         Media  `json:"node"`
+		} `json:"edges"`
+	} `json:"relations"`
+	ExternalLinks []struct {
+		Site string `json:"site"`
+		URL  string `json:"url"`
+	} `json:"externalLinks"`
+}
+
+// Recommendation is an anime users recommend for another one. Rating is the
+// net number of users agreeing.
+type Recommendation struct {
+	Rating int    `json:"rating"`
+	Media  *Media `json:"mediaRecommendation"`
+}
+
+// FranchiseNode is one anime of a franchise with its relations to other anime
+type FranchiseNode struct {
+	Media
+	StartDate FuzzyDate           `json:"startDate"`
+	Relations []FranchiseRelation `json:"-"`
+}
+
+// FranchiseRelation links a franchise node to another anime, e.g. SEQUEL
+type FranchiseRelation struct {
+	Type    string
+	MediaID int
+}
+
+// ListActivity is a list update of a user, e.g. "watched episode 5 of X".
+// Progress is text since it may be a range like "4 - 6".
+type ListActivity struct {
+	ID        int    `json:"id"`
+	Status    string `json:"status"`
+	Progress  string `json:"progress"`
+	CreatedAt int64  `json:"createdAt"`
+	User      User   `json:"user"`
+	Media     *Media `json:"media"`
+}
+
+// ActivityPage is one page of an activity feed
+type ActivityPage struct {
+	PageInfo   PageInfo       `json:"pageInfo"`
+	Activities []ListActivity `json:"activities"`
+}
+
+// UserMediaList is another user's list entry for an anime, with the score in
+// the format it was asked for
+type UserMediaList struct {
+	Status   string  `json:"status"`
+	Progress int     `json:"progress"`
+	Score    float64 `json:"score"`
+	User     User    `json:"user"`
+}
+
+// Notification is one entry of the viewer's inbox. Which fields are set
+// depends on Type: AIRING has Episode, Contexts and Media, RELATED_MEDIA_ADDITION
+// has Context and Media, FOLLOWING and the ACTIVITY_* types Context and User.
+type Notification struct {
+	ID         int      `json:"id"`
+	Type       string   `json:"type"`
+	CreatedAt  int64    `json:"createdAt"`
+	Episode    int      `json:"episode"`
+	Contexts   []string `json:"contexts"`
+	Context    string   `json:"context"`
+	Media      *Media   `json:"media"`
+	User       *User    `json:"user"`
+	ActivityID int      `json:"activityId"`
+}
+
+// NotificationPage is one page of the viewer's inbox
+type NotificationPage struct {
+	PageInfo      PageInfo       `json:"pageInfo"`
+	Notifications []Notification `json:"notifications"`
+}
+
+// AnimeStatistics is a user's anime statistics as on their AniList stats page
+type AnimeStatistics struct {
+	Count             int               `json:"count"`
+	MeanScore         float64           `json:"meanScore"`
+	StandardDeviation float64           `json:"standardDeviation"`
+	MinutesWatched    int               `json:"minutesWatched"`
+	EpisodesWatched   int               `json:"episodesWatched"`
+	Scores            []ScoreStat       `json:"scores"`
+	Genres            []GenreStat       `json:"genres"`
+	Formats           []FormatStat      `json:"formats"`
+	ReleaseYears      []ReleaseYearStat `json:"releaseYears"`
+	Studios           []StudioStat      `json:"studios"`
+}
+
+// ScoreStat counts the anime given one score, in the user's score format
+type ScoreStat struct {
+	Score int `json:"score"`
+	Count int `json:"count"`
+}
+
+type GenreStat struct {
+	Genre          string  `json:"genre"`
+	Count          int     `json:"count"`
+	MeanScore      float64 `json:"meanScore"`
+	MinutesWatched int     `json:"minutesWatched"`
+}
+
+type FormatStat struct {
+	Format string `json:"format"`
+	Count  int    `json:"count"`
+}
+
+type ReleaseYearStat struct {
+	ReleaseYear int `json:"releaseYear"`
+	Count       int `json:"count"`
+}
+
+type StudioStat struct {
+	Studio struct {
+		Name string `json:"name"`
+	} `json:"studio"`
+	Count     int     `json:"count"`
+	MeanScore float64 `json:"meanScore"`
+}
+
+type AiringSchedule struct {
+	ID              int   `json:"id"`
+	AiringAt        int64 `json:"airingAt"`
+	TimeUntilAiring int64 `json:"timeUntilAiring"`
+	Episode         int   `json:"episode"`
+	Media           Media `json:"media"`
+}
+
+type NextAiringEpisode struct {
+	AiringAt        int64 `json:"airingAt"`
+	TimeUntilAiring int64 `json:"timeUntilAiring"`
+	Episode         int   `json:"episode"`
+}
+
+// FuzzyDate is AniList's date type where any part may be unknown
+type FuzzyDate struct {
+	Year  *int `json:"year"`
+	Month *int `json:"month"`
+	Day   *int `json:"day"`
+}
+
+func (d FuzzyDate) String() string {
+	if d.Year == nil {
+		return "?"
+	}
+	if d.Month == nil {
+		return fmt.Sprintf("%d", *d.Year)
+	}
+	t := time.Date(*d.Year, time.Month(*d.Month), 1, 0, 0, 0, 0, time.UTC)
+	if d.Day == nil {
+		return t.Format("Jan 2006")
+	}
+	return t.AddDate(0, 0, *d.Day-1).Format("Jan 02, 2006")
+}
+
+type PageInfo struct {
+	Total       int  `json:"total"`
+	PerPage     int  `json:"perPage"`
+	CurrentPage int  `json:"currentPage"`
+	LastPage    int  `json:"lastPage"`
+	HasNextPage bool `json:"hasNextPage"`
+}
+
+// MediaPage is one page of a media listing
+type MediaPage struct {
+	PageInfo PageInfo `json:"pageInfo"`
+	Media    []Media  `json:"media"`
+}
+
+// User is an AniList user other than the viewer
+type User struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Viewer is the user the access token belongs to, with the settings that
+// change how media and scores are shown
+type Viewer struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Options struct {
+		TitleLanguage string `json:"titleLanguage"`
+	} `json:"options"`
+	MediaListOptions struct {
+		ScoreFormat string `json:"scoreFormat"`
+	} `json:"mediaListOptions"`
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// animeToshoSource searches AnimeTosho's JSON feed, which mirrors Nyaa and
+// other trackers
+type animeToshoSource struct{}
+
+func (animeToshoSource) Name() string {
+	return "animetosho"
+}
+
+func (animeToshoSource) Search(ctx context.Context, query string) ([]Torrent, error) {
+	apiURL := fmt.Sprintf("https://feed.animetosho.org/json?qx=1&q=%s", url.QueryEscape(query))
+	resp, err := getTorrentFeed(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var torrents []Torrent
+	if err := json.NewDecoder(resp.Body).Decode(&torrents); err != nil {
+		return nil, fmt.Errorf("decoding feed: %w", err)
+	}
+	for i := range torrents {
+		torrents[i].ID = i
+		torrents[i].Source = "animetosho"
+	}
+	return torrents, nil
+}
@@ -1,250 +1,298 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/pkg/browser"
+	"github.com/sunnygitgud/sakuhaku/anilist"
 )
 
 var (
-	authURL      = "https://anilist.co/api/v2/oauth/authorize"
-	tokenURL     = "https://anilist.co/api/v2/oauth/token"
-	callbackPort = "8888"
-	tokenFile    = ".anilist_token"
+	authURL   = "https://anilist.co/api/v2/oauth/authorize"
+	tokenURL  = "https://anilist.co/api/v2/oauth/token"
+	pinURL    = "https://anilist.co/api/v2/oauth/pin"
+	tokenFile = ".anilist_token"
 )
 
 //OAuth Messages
 
 type authSuccessMsg struct {
-	token    string
-	username string
-	userID   int
+	tracker string
+	token   authToken
+	viewer  *anilist.Viewer
 }
 
 type authErrorMsg struct {
 	err error
 }
 
-type userListMsg []UserAnimeEntry
+// userListMsg carries one page of a browse list shown in ModeUserList
+type userListMsg struct {
+	listType    ListType
+	entries     []UserAnimeEntry
+	page        int
+	hasNextPage bool
+	refresh     bool // a background refresh, it never changes the view or the loading state
+}
 
 // OAuth Implementation
-func startOAuthFlow() tea.Cmd {
+
+// How long the browser login waits for AniList to redirect back
+const loginTimeout = 5 * time.Minute
+
+var errLoginCancelled = errors.New("login cancelled")
+
+// startOAuthFlow runs the authorization code login of a tracker. It serves
+// the callback on its own server and mux, which are shut down when the flow
+// ends, so a failed or cancelled login can simply be started again.
+func startOAuthFlow(ctx context.Context, tracker Tracker, redirectURI string) tea.Cmd {
 	return func() tea.Msg {
-		// Start local server to receive callback
+		redirect, err := url.Parse(redirectURI)
+		if err != nil {
+			return authErrorMsg{err: fmt.Errorf("invalid redirect URI: %w", err)}
+		}
+		callbackPath := redirect.Path
+		if callbackPath == "" {
+			callbackPath = "/"
+		}
+
+		state, err := randomHex(16)
+		if err != nil {
+			return authErrorMsg{err: err}
+		}
+		verifier, err := randomHex(32)
+		if err != nil {
+			return authErrorMsg{err: err}
+		}
+
+		// Listen before opening the browser so a busy port fails right away
+		listener, err := net.Listen("tcp", net.JoinHostPort(oauthCallbackHost(redirect), oauthCallbackPort(redirect)))
+		if err != nil {
+			return authErrorMsg{err: fmt.Errorf("starting callback server: %w", err)}
+		}
+
 		codeChan := make(chan string, 1)
 		errChan := make(chan error, 1)
 
-		server := &http.Server{Addr: ":" + callbackPort}
-
-		http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-			code := r.URL.Query().Get("code")
+		mux := http.NewServeMux()
+		mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+				http.Error(w, "invalid state", http.StatusBadRequest)
+				return
+			}
+			if e := query.Get("error"); e != "" {
+				http.Error(w, "authorization failed", http.StatusBadRequest)
+				sendOnce(errChan, fmt.Errorf("authorization denied: %s %s", e, query.Get("error_description")))
+				return
+			}
+			code := query.Get("code")
 			if code == "" {
-				errChan <- fmt.Errorf("no code received")
+				http.Error(w, "no code received", http.StatusBadRequest)
+				sendOnce(errChan, fmt.Errorf("no code received"))
 				return
 			}
 
 			w.Header().Set("Content-Type", "text/html")
 			fmt.Fprintf(w, "<html><body><h1>Authentication successful!</h1><p>You can close this window and return to the terminal.</p></body></html>")
 
-			codeChan <- code
+			sendOnce(codeChan, code)
 		})
 
+		server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
 		go func() {
-			if err := server.ListenAndServe(); err != http.ErrServerClosed {
-				errChan <- err
+			if err := server.Serve(listener); err != http.ErrServerClosed {
+				sendOnce(errChan, err)
 			}
 		}()
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(shutdownCtx)
+		}()
 
 		// Open browser for authentication
-		authURLWithParams := fmt.Sprintf("%s?client_id=%s&redirect_uri=%s&response_type=code",
-			authURL, clientID, url.QueryEscape(redirectURI))
-
-		if err := browser.OpenURL(authURLWithParams); err != nil {
-			return authErrorMsg{err: err}
+		if err := browser.OpenURL(tracker.AuthCodeURL(redirect.String(), state, verifier)); err != nil {
+			return authErrorMsg{err: fmt.Errorf("opening browser: %w", err)}
 		}
 
-		// Wait for callback with timeout
+		// Wait for callback, cancellation or timeout
 		select {
 		case code := <-codeChan:
-			server.Shutdown(context.Background())
-
-			// Exchange code for token
-			token, err := exchangeCodeForToken(code)
+			token, err := tracker.ExchangeCode(ctx, code, redirect.String(), verifier)
 			if err != nil {
 				return authErrorMsg{err: err}
 			}
 
-			// Get user info
-			username, userID, err := getUserInfo(token)
+			viewer, err := getUserInfo(tracker, token.AccessToken)
 			if err != nil {
 				return authErrorMsg{err: err}
 			}
 
-			return authSuccessMsg{
-				token:    token,
-				username: username,
-				userID:   userID,
-			}
+			return authSuccessMsg{tracker: tracker.Name(), token: *token, viewer: viewer}
 
 		case err := <-errChan:
-			server.Shutdown(context.Background())
 			return authErrorMsg{err: err}
 
-		case <-time.After(5 * time.Minute):
-			server.Shutdown(context.Background())
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return authErrorMsg{err: errLoginCancelled}
+			}
 			return authErrorMsg{err: fmt.Errorf("authentication timeout")}
 		}
 	}
 }
 
-func exchangeCodeForToken(code string) (string, error) {
-	data := url.Values{
-		"grant_type":    {"authorization_code"},
-		"client_id":     {clientID},
-		"client_secret": {clientSecret},
-		"redirect_uri":  {redirectURI},
-		"code":          {code},
-	}
-
-	resp, err := http.PostForm(tokenURL, data)
-	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read the full response for debugging
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Log the response for debugging
-	fmt.Printf("Token exchange response: %+v\n", result)
-	fmt.Printf("HTTP Status: %d\n", resp.StatusCode)
-
-	// Check for error in response
-	if errMsg, ok := result["error"]; ok {
-		return "", fmt.Errorf("OAuth error: %v - %v", errMsg, result["error_description"])
+// endLogin releases the context of a finished browser login
+func (m *model) endLogin() {
+	if m.cancelLogin != nil {
+		m.cancelLogin()
+		m.cancelLogin = nil
 	}
+}
 
-	// Try to get access token
-	accessToken, ok := result["access_token"].(string)
-	if !ok || accessToken == "" {
-		return "", fmt.Errorf("no access token in response: %+v", result)
+// startSession switches the app to a logged in account, showing titles and
+// scores the way the account's settings ask for. AniList is only asked with
+// the token of AniList accounts.
+func (m *model) startSession(tracker, token string, viewer *anilist.Viewer) {
+	m.accessToken = token
+	m.trackerName = tracker
+	if tracker == trackerMyAnimeList {
+		m.mal = m.mal.WithToken(token)
+	} else {
+		m.aniList = m.aniList.WithToken(token)
 	}
+	m.username = viewer.Name
+	m.userID = viewer.ID
+	m.titleLanguage = viewer.Options.TitleLanguage
+	m.scoreFormat = scoreFormat(viewer.MediaListOptions.ScoreFormat)
+	m.session++
+}
 
-	return accessToken, nil
+// endSession forgets the logged in account and everything loaded for it
+func (m *model) endSession() {
+	m.accessToken = ""
+	m.aniList = m.aniList.WithToken("")
+	m.mal = m.mal.WithToken("")
+	m.trackerName = ""
+	m.username = ""
+	m.userID = 0
+	m.titleLanguage = ""
+	m.scoreFormat = ""
+	m.session++
+	m.unreadNotifications = 0
+	m.notifications = nil
+	m.collection = nil
+	m.userEntries = nil
+	m.userEntryCursor = 0
+	m.listTabIndex = 0
+	m.currentListType = ListCurrentlyWatching
 }
 
-func getUserInfo(token string) (string, int, error) {
-	query := `
-	query {
-		Viewer {
-			id
-			name
-		}
+// sendOnce delivers v unless a value is already waiting, so repeated
+// callback requests never block the handler
+func sendOnce[T any](ch chan T, v T) {
+	select {
+	case ch <- v:
+	default:
 	}
-	`
+}
 
-	result, err := makeAuthenticatedRequest(token, query, nil)
-	if err != nil {
-		return "", 0, err
+// randomHex returns n random bytes hex encoded, for the OAuth state that ties
+// the callback to this login and the PKCE verifier
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+	return hex.EncodeToString(b), nil
+}
 
-	return result.Data.Viewer.Name, result.Data.Viewer.ID, nil
+// pinAuthURL is the implicit grant authorize URL. AniList then shows the token
+// on its PIN page, which needs pinURL as the redirect URL of the AniList client.
+func pinAuthURL() string {
+	return fmt.Sprintf("%s?client_id=%s&response_type=token", authURL, url.QueryEscape(pinClientID()))
 }
 
-func fetchUserAnimeList(token string, userID int, status string) tea.Cmd {
+// loginWithToken logs in with a saved or pasted token, refreshing it first
+// when it is about to expire
+func loginWithToken(tracker Tracker, token authToken) tea.Cmd {
 	return func() tea.Msg {
-		query := `
-		query ($userId: Int, $status: MediaListStatus) {
-			MediaListCollection(userId: $userId, type: ANIME, status: $status, sort: UPDATED_TIME_DESC) {
-				lists {
-					name
-					entries {
-						id
-						status
-						progress
-						score
-						updatedAt
-						media {
-							id
-							title {
-								romaji
-								english
-							}
-							format
-							status
-							episodes
-							averageScore
-							season
-							seasonYear
-							coverImage {
-								large
-							}
-							siteUrl
-						}
-					}
-				}
-			}
-		}
-		`
-
-		variables := map[string]interface{}{
-			"userId": userID,
-			"status": status,
-		}
-
-		result, err := makeAuthenticatedRequest(token, query, variables)
+		ctx, cancel := aniListContext()
+		defer cancel()
+		token, err := freshToken(ctx, tracker, token)
 		if err != nil {
-			return userListMsg(nil)
+			return authErrorMsg{err: fmt.Errorf("refreshing %s token: %w", tracker.Name(), err)}
 		}
 
-		var entries []UserAnimeEntry
-		for _, list := range result.Data.MediaListCollection.Lists {
-			entries = append(entries, list.Entries...)
+		viewer, err := getUserInfo(tracker, token.AccessToken)
+		if err != nil {
+			return authErrorMsg{err: err}
 		}
-
-		return userListMsg(entries)
+		return authSuccessMsg{tracker: tracker.Name(), token: token, viewer: viewer}
 	}
 }
 
-func makeAuthenticatedRequest(token, query string, variables map[string]interface{}) (*AniListResponse, error) {
-	requestBody := map[string]interface{}{
-		"query":     query,
-		"variables": variables,
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, err
+func exchangeCodeForToken(ctx context.Context, code, redirect string) (string, error) {
+	data := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"redirect_uri":  {redirect},
+		"code":          {code},
 	}
 
-	req, err := http.NewRequest("POST", "https://graphql.anilist.co", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	var result AniListResponse
+	// Read the full response for debugging
+	var result map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// The response holds the token, so only its status is logged
+	debugLog(fmt.Sprintf("Token exchange status: %d", resp.StatusCode))
+
+	// Check for error in response
+	if errMsg, ok := result["error"]; ok {
+		return "", fmt.Errorf("OAuth error: %v - %v", errMsg, result["error_description"])
+	}
+
+	// Try to get access token
+	accessToken, ok := result["access_token"].(string)
+	if !ok || accessToken == "" {
+		return "", fmt.Errorf("no access token in response (HTTP %d)", resp.StatusCode)
 	}
 
-	return &result, nil
+	return accessToken, nil
+}
+
+func getUserInfo(tracker Tracker, token string) (*anilist.Viewer, error) {
+	ctx, cancel := aniListContext()
+	defer cancel()
+
+	return tracker.WithToken(token).Viewer(ctx)
 }
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sunnygitgud/sakuhaku/mal"
+)
+
+// Settings are read from the environment, which init() in main.go populates
+// from the .env file
+
+// envString returns the value of key, or def when it is unset
+func envString(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+// envInt returns the integer value of key, or def when it is unset or invalid
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// watchThreshold prefers the active profile's threshold over .env
+func (m *model) watchThreshold() float64 {
+	if p := m.profiles.active(); p != nil {
+		if t := p.Preferences.WatchThreshold; t > 0 && t <= 100 {
+			return float64(t)
+		}
+	}
+	return watchThreshold()
+}
+
+// cachePath is where AniList responses are cached for offline use
+func cachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return envString("SAKUHAKU_CACHE_PATH", filepath.Join(dir, "sakuhaku", "anilist.db"))
+}
+
+// oauthRedirectURI is the redirect URL registered for the AniList client
+func oauthRedirectURI() string {
+	return envString("ANILIST_REDIRECT_URI", redirectURI)
+}
+
+// oauthCallbackPort is the local port the login callback listens on. It
+// defaults to the redirect URL's port, but may differ behind a proxy.
+func oauthCallbackPort(redirect *url.URL) string {
+	port := redirect.Port()
+	if port == "" {
+		port = "8888"
+	}
+	return envString("ANILIST_CALLBACK_PORT", port)
+}
+
+// oauthCallbackHost is the address the login callback listens on. Only
+// loopback is reachable by default, so nobody else on the network can hit the
+// callback while a login is open.
+func oauthCallbackHost(redirect *url.URL) string {
+	host := "127.0.0.1"
+	if ip := net.ParseIP(redirect.Hostname()); ip != nil {
+		host = ip.String()
+	}
+	return envString("ANILIST_CALLBACK_HOST", host)
+}
+
+// pinClientID is the AniList client used for the PIN login. Its redirect URL
+// has to be the PIN page, so it may differ from the browser login client.
+func pinClientID() string {
+	return envString("ANILIST_PIN_CLIENT_ID", clientID)
+}
+
+// watchThreshold is the playback percentage after which an episode counts as watched
+func watchThreshold() float64 {
+	threshold := envInt("SAKUHAKU_WATCH_THRESHOLD", 85)
+	if threshold <= 0 || threshold > 100 {
+		threshold = 85
+	}
+	return float64(threshold)
+}
+
+// exportDir is where list exports are written and imports are looked for
+func exportDir() string {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		dir = "."
+	}
+	return expandHome(envString("SAKUHAKU_EXPORT_DIR", dir))
+}
+
+// Default time a torrent source has to answer every query of a search
+const defaultTorrentTimeout = 15 * time.Second
+
+// enabledTorrentSources are the sources named in SAKUHAKU_TORRENT_SOURCES,
+// comma separated, or all of them
+func enabledTorrentSources() []TorrentSource {
+	names := envString("SAKUHAKU_TORRENT_SOURCES", "")
+	if names == "" {
+		return torrentSources
+	}
+	var sources []TorrentSource
+	for _, s := range torrentSources {
+		for _, name := range strings.Split(names, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), s.Name()) {
+				sources = append(sources, s)
+				break
+			}
+		}
+	}
+	return sources
+}
+
+// torrentSourceTimeout is SAKUHAKU_TORRENT_TIMEOUT_<NAME> in seconds, or
+// SAKUHAKU_TORRENT_TIMEOUT for every source
+func torrentSourceTimeout(name string) time.Duration {
+	seconds := envInt("SAKUHAKU_TORRENT_TIMEOUT", 0)
+	seconds = envInt("SAKUHAKU_TORRENT_TIMEOUT_"+strings.ToUpper(name), seconds)
+	if seconds <= 0 {
+		return defaultTorrentTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// localListPath is the file the list is kept in while nobody is logged in
+func localListPath() string {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		dir = "."
+	}
+	return expandHome(envString("SAKUHAKU_LOCAL_LIST", filepath.Join(dir, ".sakuhaku_list.json")))
+}
+
+// expandHome resolves a leading ~ the way a shell would
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// newMalClient is the MyAnimeList API client. MAL_API_URL and MAL_AUTH_URL
+// point it at another server, e.g. a local stand-in.
+func newMalClient() *mal.Client {
+	client := mal.NewClient(envString("MAL_CLIENT_ID", ""))
+	client.ClientSecret = envString("MAL_CLIENT_SECRET", "")
+	client.Endpoint = strings.TrimSuffix(envString("MAL_API_URL", mal.Endpoint), "/")
+	client.AuthEndpoint = strings.TrimSuffix(envString("MAL_AUTH_URL", mal.AuthEndpoint), "/")
+	return client
+}
+
+// malRedirectURI is the redirect URL registered for the MyAnimeList client,
+// the AniList one unless set
+func malRedirectURI() string {
+	return envString("MAL_REDIRECT_URI", oauthRedirectURI())
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+// Entry editor field order
+const (
+	editStatus = iota
+	editProgress
+	editScore
+	editRepeat
+	editStartedAt
+	editCompletedAt
+	editNotes
+	editPrivate
+	editHidden
+	editCustomLists
+)
+
+var yesNo = []string{"No", "Yes"}
+
+// entryEditor is the modal editor of every field of a list entry
+type entryEditor struct {
+	form          *form
+	scores        scoreFormat
+	entry         UserAnimeEntry // as loaded from AniList, the zero entry for new ones
+	customLists   []string       // the user's custom anime lists
+	err           string
+	confirmDelete bool
+}
+
+type entryDetailsMsg struct {
+	media       Anime
+	entry       *UserAnimeEntry
+	customLists []string
+	err         error
+}
+
+// listEntryDeletedMsg carries the outcome of deleting a list entry
+type listEntryDeletedMsg struct {
+	entry UserAnimeEntry
+	err   error
+}
+
+func fetchEntryDetails(client *anilist.Client, media Anime) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		// Edits go straight to AniList, so they start from its current state
+		entry, customLists, err := client.WithCachePolicy(anilist.NetworkFirst).MediaListDetails(ctx, media.ID)
+		if entry != nil {
+			entry.Media = media
+		}
+		return entryDetailsMsg{media: media, entry: entry, customLists: customLists, err: err}
+	}
+}
+
+func saveEntryDetails(client *anilist.Client, seq int, prev, entry UserAnimeEntry) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		customLists := []string{}
+		for _, l := range entry.CustomLists {
+			if l.Enabled {
+				customLists = append(customLists, l.Name)
+			}
+		}
+		saved, err := client.SaveMediaListEntry(ctx, anilist.SaveMediaListEntryInput{
+			MediaID:               entry.Media.ID,
+			Status:                entry.Status,
+			Progress:              entry.Progress,
+			Score:                 entry.Score,
+			Repeat:                &entry.Repeat,
+			Notes:                 &entry.Notes,
+			Private:               &entry.Private,
+			HiddenFromStatusLists: &entry.HiddenFromStatusLists,
+			StartedAt:             &entry.StartedAt,
+			CompletedAt:           &entry.CompletedAt,
+			CustomLists:           customLists,
+		})
+		if err != nil {
+			return listEntrySavedMsg{seq: seq, prev: prev, entry: entry, err: err}
+		}
+
+		saved.Media = entry.Media
+		return listEntrySavedMsg{seq: seq, prev: prev, entry: *saved}
+	}
+}
+
+func deleteListEntry(tracker Tracker, entry UserAnimeEntry) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		err := tracker.DeleteEntry(ctx, entry)
+		return listEntryDeletedMsg{entry: entry, err: err}
+	}
+}
+
+// openEntryEditor loads the full list entry of the selected anime
+func (m *model) openEntryEditor() tea.Cmd {
+	if !m.requireAniList("edit every field of your entries") {
+		return nil
+	}
+	selected, ok := m.selectedEntry()
+	if !ok {
+		return nil
+	}
+	m.loading = true
+	m.loadingMsg = "Loading list entry..."
+	return tea.Batch(m.spinner.Tick, fetchEntryDetails(m.aniList, selected.Media))
+}
+
+func newEntryEditor(msg entryDetailsMsg, scores scoreFormat) *entryEditor {
+	entry := UserAnimeEntry{Media: msg.media, Status: "PLANNING"}
+	if msg.entry != nil {
+		entry = *msg.entry
+	}
+
+	var onLists []string
+	for _, l := range entry.CustomLists {
+		if l.Enabled {
+			onLists = append(onLists, l.Name)
+		}
+	}
+	listsHint := "comma separated"
+	if len(msg.customLists) > 0 {
+		listsHint = strings.Join(msg.customLists, ", ")
+	}
+
+	editor := &entryEditor{
+		entry:       entry,
+		scores:      scores,
+		customLists: msg.customLists,
+		form: newForm(
+			choiceField("Status", listStatuses, entry.Status),
+			textField("Progress", strconv.Itoa(entry.Progress), "episodes watched"),
+			textField("Score", strconv.FormatFloat(entry.Score, 'f', -1, 64), scores.hint()+", 0 for none"),
+			textField("Rewatches", strconv.Itoa(entry.Repeat), "times rewatched"),
+			textField("Started", fuzzyDateInput(entry.StartedAt), "YYYY-MM-DD, YYYY-MM or YYYY"),
+			textField("Completed", fuzzyDateInput(entry.CompletedAt), "YYYY-MM-DD, YYYY-MM or YYYY"),
+			textField("Notes", entry.Notes, ""),
+			choiceField("Private", yesNo, yesNo[boolIndex(entry.Private)]),
+			choiceField("Hide in lists", yesNo, yesNo[boolIndex(entry.HiddenFromStatusLists)]),
+			textField("Custom lists", strings.Join(onLists, ", "), listsHint),
+		),
+	}
+	if msg.entry == nil {
+		editor.err = "Not on your list yet, saving adds it"
+	}
+	return editor
+}
+
+// edited validates the form and returns the edited entry
+func (e *entryEditor) edited() (UserAnimeEntry, error) {
+	f := e.form
+	entry := e.entry
+	entry.Status = f.value(editStatus)
+
+	var err error
+	if entry.Progress, err = strconv.Atoi(f.value(editProgress)); err != nil || entry.Progress < 0 {
+		return entry, fmt.Errorf("progress must be a whole number")
+	}
+	if eps := entry.Media.Episodes; eps != nil && entry.Progress > *eps {
+		return entry, fmt.Errorf("progress can't be more than %d episodes", *eps)
+	}
+	if entry.Score, err = e.scores.parse(f.value(editScore)); err != nil {
+		return entry, err
+	}
+	if entry.Repeat, err = strconv.Atoi(f.value(editRepeat)); err != nil || entry.Repeat < 0 {
+		return entry, fmt.Errorf("rewatches must be a whole number")
+	}
+	if entry.StartedAt, err = parseFuzzyDate(f.value(editStartedAt)); err != nil {
+		return entry, fmt.Errorf("started: %w", err)
+	}
+	if entry.CompletedAt, err = parseFuzzyDate(f.value(editCompletedAt)); err != nil {
+		return entry, fmt.Errorf("completed: %w", err)
+	}
+	entry.Notes = f.value(editNotes)
+	entry.Private = f.value(editPrivate) == "Yes"
+	entry.HiddenFromStatusLists = f.value(editHidden) == "Yes"
+
+	enabled := map[string]bool{}
+	for _, name := range splitList(f.value(editCustomLists)) {
+		list, ok := matchFold(e.customLists, name)
+		if !ok {
+			return entry, fmt.Errorf("you have no custom list %q", name)
+		}
+		enabled[list] = true
+	}
+	entry.CustomLists = nil
+	for _, name := range e.customLists {
+		entry.CustomLists = append(entry.CustomLists, anilist.CustomListMembership{Name: name, Enabled: enabled[name]})
+	}
+	return entry, nil
+}
+
+func (m *model) handleEntryEditorKeys(msg tea.KeyMsg) tea.Cmd {
+	e := m.entryEditor
+	if e.confirmDelete {
+		e.confirmDelete = false
+		if msg.String() != "y" {
+			e.err = ""
+			m.viewport.SetContent(m.renderContent())
+			return nil
+		}
+		m.entryEditor = nil
+		m.statusMsg = fmt.Sprintf("Deleting from %s...", m.listTracker().Name())
+		m.viewport.SetContent(m.renderContent())
+		return deleteListEntry(m.listTracker(), e.entry)
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.entryEditor = nil
+	case "ctrl+d":
+		if e.entry.ID == 0 {
+			e.err = "This anime isn't on your list"
+			break
+		}
+		e.confirmDelete = true
+		e.err = fmt.Sprintf("Delete %s from your list? y to confirm", m.animeTitle(e.entry.Media))
+	case "enter":
+		next, err := e.edited()
+		if err != nil {
+			e.err = err.Error()
+			break
+		}
+		m.entryEditor = nil
+		prev := e.entry
+		if prev.ID == 0 {
+			prev = entryFromMedia(prev.Media)
+		}
+		// Roll back to the custom lists as they are shown, not as loaded
+		prev.CustomLists = m.customListMembership(prev.Media.ID, e.customLists)
+		m.storeEntry(next)
+		m.storeCustomLists(next)
+		m.statusMsg = fmt.Sprintf("Saving to %s...", m.listTracker().Name())
+		m.refreshTab()
+		return saveEntryDetails(m.aniList, m.nextEntrySave(next.Media.ID), prev, next)
+	default:
+		e.form.update(msg)
+	}
+	m.viewport.SetContent(m.renderContent())
+	return nil
+}
+
+// refreshTab re-reads the current personal tab after entries moved between lists
+func (m *model) refreshTab() {
+	if m.mode == ModeUserList && m.currentListType.Personal() && m.collection != nil {
+		m.showTab()
+	} else if m.ready {
+		m.viewport.SetContent(m.renderContent())
+	}
+}
+
+func (m *model) renderEntryEditor() string {
+	e := m.entryEditor
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("✏️  %s\n\n", m.animeTitle(e.entry.Media)))
+	sb.WriteString(e.form.view())
+	if e.err != "" {
+		sb.WriteString(fmt.Sprintf("\n⚠ %s\n", e.err))
+	}
+	return sb.String()
+}
+
+// parseFuzzyDate accepts "2024-03-05", "2024-03", "2024" or nothing
+func parseFuzzyDate(s string) (FuzzyDate, error) {
+	var d FuzzyDate
+	if s == "" {
+		return d, nil
+	}
+	parts := strings.Split(s, "-")
+	if len(parts) > 3 {
+		return d, fmt.Errorf("invalid date %q", s)
+	}
+	limits := []int{9999, 12, 31}
+	fields := []**int{&d.Year, &d.Month, &d.Day}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > limits[i] {
+			return FuzzyDate{}, fmt.Errorf("invalid date %q", s)
+		}
+		*fields[i] = &n
+	}
+	// Days past the end of the month roll over in time.Date
+	if d.Day != nil {
+		t := time.Date(*d.Year, time.Month(*d.Month), *d.Day, 0, 0, 0, 0, time.UTC)
+		if t.Day() != *d.Day {
+			return FuzzyDate{}, fmt.Errorf("invalid date %q", s)
+		}
+	}
+	return d, nil
+}
+
+// fuzzyDateInput formats a date the way parseFuzzyDate reads it
+func fuzzyDateInput(d FuzzyDate) string {
+	switch {
+	case d.Year == nil:
+		return ""
+	case d.Month == nil:
+		return fmt.Sprintf("%04d", *d.Year)
+	case d.Day == nil:
+		return fmt.Sprintf("%04d-%02d", *d.Year, *d.Month)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", *d.Year, *d.Month, *d.Day)
+}
+
+func boolIndex(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+func TestParseFuzzyDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string // as fuzzyDateInput formats it
+		wantErr bool
+	}{
+		{"", "", false},
+		{"2024", "2024", false},
+		{"2024-03", "2024-03", false},
+		{"2024-03-05", "2024-03-05", false},
+		{"2024-02-29", "2024-02-29", false},
+		{"2024-02-30", "", true},
+		{"2024-02-31", "", true},
+		{"2023-02-29", "", true},
+		{"2023-04-31", "", true},
+		{"2023-13", "", true},
+		{"2023-00-10", "", true},
+		{"2023-01-32", "", true},
+		{"2023-01-05-01", "", true},
+		{"march", "", true},
+		{"2023--05", "", true},
+	}
+	for _, tt := range tests {
+		d, err := parseFuzzyDate(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFuzzyDate(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got := fuzzyDateInput(d); got != tt.want {
+			t.Errorf("parseFuzzyDate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCustomListMembershipRollsBack(t *testing.T) {
+	anime := Anime{ID: 7}
+	m := &model{collection: []anilist.MediaListGroup{
+		{Name: "Favourites", IsCustomList: true, Entries: []UserAnimeEntry{{Media: anime}}},
+		{Name: "Rewatch", IsCustomList: true},
+	}}
+
+	prev := UserAnimeEntry{Media: anime, CustomLists: m.customListMembership(anime.ID, []string{"Favourites", "Rewatch"})}
+	m.storeCustomLists(UserAnimeEntry{Media: anime, CustomLists: []anilist.CustomListMembership{
+		{Name: "Favourites", Enabled: false},
+		{Name: "Rewatch", Enabled: true},
+	}})
+	if len(m.collection[0].Entries) != 0 || len(m.collection[1].Entries) != 1 {
+		t.Fatalf("edit not applied: %+v", m.collection)
+	}
+
+	m.storeCustomLists(prev)
+	if len(m.collection[0].Entries) != 1 || len(m.collection[1].Entries) != 0 {
+		t.Errorf("rollback left %+v", m.collection)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	formLabelStyle   = lipgloss.NewStyle().Width(14)
+	formFocusedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	formHintStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// formField is either a free text input or, when choices are set, a value
+// picked with ←/→
+type formField struct {
+	label   string
+	hint    string
+	input   textinput.Model
+	choices []string
+	choice  int
+}
+
+// form is a vertical stack of fields used by the modal editors
+type form struct {
+	fields []*formField
+	focus  int
+}
+
+func textField(label, value, hint string) *formField {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.SetValue(value)
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	return &formField{label: label, hint: hint, input: ti}
+}
+
+// choiceField starts at value, or at the first choice when value isn't one of them
+func choiceField(label string, choices []string, value string) *formField {
+	f := &formField{label: label, choices: choices}
+	for i, c := range choices {
+		if c == value {
+			f.choice = i
+		}
+	}
+	return f
+}
+
+func newForm(fields ...*formField) *form {
+	f := &form{fields: fields}
+	f.setFocus(0)
+	return f
+}
+
+func (f *formField) value() string {
+	if f.choices != nil {
+		return f.choices[f.choice]
+	}
+	return strings.TrimSpace(f.input.Value())
+}
+
+func (f *form) value(i int) string {
+	return f.fields[i].value()
+}
+
+func (f *form) setFocus(i int) {
+	f.fields[f.focus].input.Blur()
+	f.focus = (i + len(f.fields)) % len(f.fields)
+	if f.fields[f.focus].choices == nil {
+		f.fields[f.focus].input.Focus()
+	}
+}
+
+// update handles field navigation and editing. Keys that submit or cancel
+// the form are left to the caller.
+func (f *form) update(msg tea.KeyMsg) {
+	field := f.fields[f.focus]
+	switch msg.String() {
+	case "tab", "down":
+		f.setFocus(f.focus + 1)
+	case "shift+tab", "up":
+		f.setFocus(f.focus - 1)
+	case "left":
+		if field.choices != nil {
+			field.choice = (field.choice - 1 + len(field.choices)) % len(field.choices)
+			return
+		}
+		field.input, _ = field.input.Update(msg)
+	case "right", " ":
+		if field.choices != nil {
+			field.choice = (field.choice + 1) % len(field.choices)
+			return
+		}
+		field.input, _ = field.input.Update(msg)
+	default:
+		if field.choices == nil {
+			field.input, _ = field.input.Update(msg)
+		}
+	}
+}
+
+func (f *form) view() string {
+	var sb strings.Builder
+	for i, field := range f.fields {
+		cursor := "  "
+		label := formLabelStyle.Render(field.label + ":")
+		if i == f.focus {
+			cursor = "▶ "
+			label = formFocusedStyle.Render(label)
+		}
+
+		value := field.input.View()
+		if field.choices != nil {
+			value = fmt.Sprintf("◀ %s ▶", field.choices[field.choice])
+		}
+
+		sb.WriteString(cursor + label + " " + value)
+		if field.hint != "" && i == f.focus {
+			sb.WriteString("  " + formHintStyle.Render(field.hint))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
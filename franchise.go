@@ -0,0 +1,348 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+// franchiseLine is one row of the rendered franchise tree
+type franchiseLine struct {
+	mediaID  int
+	relation string // relation of the parent row to this one, empty for the root
+	prefix   string // tree drawing in front of the row
+}
+
+// franchiseView is the franchise of one anime as a tree and as a watch order
+type franchiseView struct {
+	nodes     map[int]*FranchiseNode
+	tree      []franchiseLine
+	order     []int
+	showOrder bool
+	cursor    int
+}
+
+type franchiseMsg struct {
+	mediaID int
+	nodes   map[int]*FranchiseNode
+	err     error
+}
+
+func fetchFranchise(client *anilist.Client, mediaID int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		nodes, err := client.Franchise(ctx, mediaID)
+		return franchiseMsg{mediaID: mediaID, nodes: nodes, err: err}
+	}
+}
+
+// openFranchise loads the franchise of an anime from AniList
+func (m *model) openFranchise(anime Anime) tea.Cmd {
+	if !m.onAniList(anime) {
+		return nil
+	}
+	if m.mode != ModeFranchise {
+		m.franchiseReturnMode = m.mode
+	}
+	m.loading = true
+	m.loadingMsg = fmt.Sprintf("Collecting the %s franchise...", m.animeTitle(anime))
+	return tea.Batch(m.spinner.Tick, fetchFranchise(m.aniList, anime.ID))
+}
+
+// newFranchiseView builds the tree and watch order with the cursor on the
+// anime the franchise was opened for
+func newFranchiseView(mediaID int, nodes map[int]*FranchiseNode) *franchiseView {
+	v := &franchiseView{nodes: nodes, order: watchOrder(nodes)}
+	v.tree = franchiseTree(nodes, v.order[0])
+	for i, line := range v.tree {
+		if line.mediaID == mediaID {
+			v.cursor = i
+		}
+	}
+	return v
+}
+
+// rows returns the media IDs in display order
+func (v *franchiseView) rows() []int {
+	if v.showOrder {
+		return v.order
+	}
+	ids := make([]int, len(v.tree))
+	for i, line := range v.tree {
+		ids[i] = line.mediaID
+	}
+	return ids
+}
+
+func (v *franchiseView) selected() *FranchiseNode {
+	rows := v.rows()
+	if v.cursor >= len(rows) {
+		return nil
+	}
+	return v.nodes[rows[v.cursor]]
+}
+
+// toggle switches between tree and watch order, keeping the selection
+func (v *franchiseView) toggle() {
+	current := v.selected()
+	v.showOrder = !v.showOrder
+	for i, id := range v.rows() {
+		if current != nil && id == current.ID {
+			v.cursor = i
+		}
+	}
+}
+
+// dateKey sorts fuzzy dates chronologically with unknown parts last
+func dateKey(d FuzzyDate) int {
+	year, month, day := 9999, 99, 99
+	if d.Year != nil {
+		year = *d.Year
+	}
+	if d.Month != nil {
+		month = *d.Month
+	}
+	if d.Day != nil {
+		day = *d.Day
+	}
+	return year*10000 + month*100 + day
+}
+
+// watchOrder sorts a franchise by release date, while never putting a sequel
+// before its prequel. Cycles in the relations are broken by release date.
+func watchOrder(nodes map[int]*FranchiseNode) []int {
+	after := map[int][]int{}
+	pending := map[int]int{}
+	for id := range nodes {
+		pending[id] = 0
+	}
+	addEdge := func(first, then int) {
+		after[first] = append(after[first], then)
+		pending[then]++
+	}
+	for id, n := range nodes {
+		for _, r := range n.Relations {
+			// Both directions are usually listed, only count SEQUEL edges once
+			switch {
+			case r.Type == "SEQUEL":
+				addEdge(id, r.MediaID)
+			case r.Type == "PREQUEL" && !hasRelation(nodes[r.MediaID], "SEQUEL", id):
+				addEdge(r.MediaID, id)
+			}
+		}
+	}
+
+	earliest := func(ids []int) int {
+		sort.Slice(ids, func(i, j int) bool {
+			ki, kj := dateKey(nodes[ids[i]].StartDate), dateKey(nodes[ids[j]].StartDate)
+			if ki != kj {
+				return ki < kj
+			}
+			return ids[i] < ids[j]
+		})
+		return ids[0]
+	}
+
+	var order []int
+	for len(pending) > 0 {
+		var ready, rest []int
+		for id, n := range pending {
+			if n == 0 {
+				ready = append(ready, id)
+			}
+			rest = append(rest, id)
+		}
+		next := 0
+		if len(ready) > 0 {
+			next = earliest(ready)
+		} else {
+			next = earliest(rest)
+		}
+
+		order = append(order, next)
+		delete(pending, next)
+		for _, then := range after[next] {
+			if _, ok := pending[then]; ok {
+				pending[then]--
+			}
+		}
+	}
+	return order
+}
+
+func hasRelation(n *FranchiseNode, relationType string, mediaID int) bool {
+	if n == nil {
+		return false
+	}
+	for _, r := range n.Relations {
+		if r.Type == relationType && r.MediaID == mediaID {
+			return true
+		}
+	}
+	return false
+}
+
+// franchiseTree lays the franchise out as a tree below root. Every anime
+// appears once, below the closest anime it is related to.
+func franchiseTree(nodes map[int]*FranchiseNode, root int) []franchiseLine {
+	type child struct {
+		id       int
+		relation string
+	}
+	children := map[int][]child{}
+	seen := map[int]bool{root: true}
+	queue := []int{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, r := range nodes[id].Relations {
+			if seen[r.MediaID] {
+				continue
+			}
+			seen[r.MediaID] = true
+			children[id] = append(children[id], child{id: r.MediaID, relation: r.Type})
+			queue = append(queue, r.MediaID)
+		}
+	}
+
+	var lines []franchiseLine
+	var walk func(id int, relation, indent, branch string)
+	walk = func(id int, relation, indent, branch string) {
+		lines = append(lines, franchiseLine{mediaID: id, relation: relation, prefix: indent + branch})
+		kids := children[id]
+		sort.SliceStable(kids, func(i, j int) bool {
+			return dateKey(nodes[kids[i].id].StartDate) < dateKey(nodes[kids[j].id].StartDate)
+		})
+		childIndent := indent
+		switch branch {
+		case "├─ ":
+			childIndent += "│  "
+		case "└─ ":
+			childIndent += "   "
+		}
+		for i, k := range kids {
+			b := "├─ "
+			if i == len(kids)-1 {
+				b = "└─ "
+			}
+			walk(k.id, k.relation, childIndent, b)
+		}
+	}
+	walk(root, "", "", "")
+	return lines
+}
+
+// listStatusIcon marks the user's list status of an anime
+func listStatusIcon(entry *MediaListEntry) string {
+	if entry == nil {
+		return " "
+	}
+	switch entry.Status {
+	case "CURRENT":
+		return "▶"
+	case "PLANNING":
+		return "⏳"
+	case "COMPLETED":
+		return "✓"
+	case "PAUSED":
+		return "⏸"
+	case "DROPPED":
+		return "✗"
+	case "REPEATING":
+		return "↻"
+	}
+	return " "
+}
+
+func (m *model) handleFranchiseKeys(msg tea.KeyMsg) tea.Cmd {
+	v := m.franchise
+	if v == nil {
+		return nil
+	}
+	switch msg.String() {
+	case "up", "k":
+		if v.cursor > 0 {
+			v.cursor--
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+		}
+	case "down", "j":
+		if v.cursor < len(v.rows())-1 {
+			v.cursor++
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+		}
+	case "o":
+		// Switch between the tree and the watch order
+		v.toggle()
+		m.viewport.SetContent(m.renderContent())
+		m.ensureCursorVisible(1)
+	case "w":
+		if n := v.selected(); n != nil {
+			return m.planToWatch(n.Media)
+		}
+	case "enter":
+		if n := v.selected(); n != nil {
+			m.selectedAnime = &n.Media
+			m.loading = true
+			m.loadingMsg = "looking for torrents..."
+			return tea.Batch(m.spinner.Tick, m.searchTorrents(searchTitles(n.Media)...))
+		}
+	}
+	return nil
+}
+
+func (m *model) renderFranchiseContent() string {
+	v := m.franchise
+	if v == nil {
+		return "No franchise loaded."
+	}
+
+	leftWidth := m.viewport.Width / 2
+	var leftPanel strings.Builder
+	if v.showOrder {
+		leftPanel.WriteString("🧭 Watch order\n\n")
+	} else {
+		leftPanel.WriteString("🌳 Franchise\n\n")
+	}
+
+	for i, id := range v.rows() {
+		n := v.nodes[id]
+		cursor := "  "
+		if i == v.cursor {
+			cursor = "▶ "
+		}
+
+		var label string
+		if v.showOrder {
+			label = fmt.Sprintf("%2d. ", i+1)
+		} else {
+			line := v.tree[i]
+			label = line.prefix
+			if line.relation != "" {
+				label += strings.ReplaceAll(line.relation, "_", " ") + ": "
+			}
+		}
+
+		year := "?"
+		if n.StartDate.Year != nil {
+			year = fmt.Sprintf("%d", *n.StartDate.Year)
+		}
+		text := fmt.Sprintf("%s%s (%s, %s)", label, m.animeTitle(n.Media), n.Format, year)
+		if maxLen := leftWidth - 6; maxLen > 20 && len([]rune(text)) > maxLen {
+			text = string([]rune(text)[:maxLen-3]) + "..."
+		}
+		leftPanel.WriteString(fmt.Sprintf("%s%s %s\n", cursor, listStatusIcon(n.MediaListEntry), text))
+	}
+
+	var rightPanel string
+	if n := v.selected(); n != nil {
+		rightPanel = m.renderAnimePanel(&n.Media)
+	}
+	return combinePanels(leftPanel.String(), rightPanel, m.viewport.Width)
+}
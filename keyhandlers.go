@@ -1,24 +1,84 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func (m *model) handleKey(msg tea.KeyMsg) tea.Cmd {
 	// Login mode
 	if m.mode == ModeLogin {
+		if m.pinMode {
+			return m.handlePinKeys(msg)
+		}
+		if m.passPrompt != promptNone {
+			return m.handlePassphraseKeys(msg)
+		}
+		// A login is in progress, it can only be cancelled
+		if m.loading {
+			switch msg.String() {
+			case "esc":
+				if m.cancelLogin != nil {
+					m.cancelLogin()
+				}
+			case "ctrl+c":
+				return tea.Quit
+			}
+			return nil
+		}
 		switch msg.String() {
 		case "l":
+			if clientSecret == "" {
+				m.loginMsg = "Browser login needs a client secret, press 'p' to paste a token instead"
+				return nil
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), loginTimeout)
+			m.cancelLogin = cancel
 			m.loading = true
-			m.loadingMsg = "Opening browser for authentication..."
-			return tea.Batch(m.spinner.Tick, startOAuthFlow())
+			m.loadingMsg = "Waiting for AniList login in your browser... (Esc to cancel)"
+			return tea.Batch(m.spinner.Tick, startOAuthFlow(ctx, &aniListTracker{client: m.aniList}, oauthRedirectURI()))
+		case "m":
+			if m.mal.ClientID == "" {
+				m.loginMsg = "MyAnimeList login needs MAL_CLIENT_ID in .env"
+				return nil
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), loginTimeout)
+			m.cancelLogin = cancel
+			m.loading = true
+			m.loadingMsg = "Waiting for MyAnimeList login in your browser... (Esc to cancel)"
+			return tea.Batch(m.spinner.Tick, startOAuthFlow(ctx, &malTracker{client: m.mal, aniList: m.aniList}, malRedirectURI()))
+		case "p":
+			m.pinMode = true
+			m.pinInput = newSecretInput("paste your token")
+			return nil
+		case "e":
+			// Encrypt the saved tokens or change the passphrase
+			if m.profiles.locked() {
+				m.askPassphrase(promptUnlock)
+			} else {
+				m.askPassphrase(promptNew)
+			}
+			return nil
 		case "s":
-			m.mode = ModeAnimeSearch
-			m.viewport.SetContent(m.renderContent())
+			// Browse with the local list
+			m.mode = ModeUserList
+			m.loading = true
+			m.loadingMsg = "Loading your local list..."
+			return tea.Batch(m.spinner.Tick, m.fetchCurrentList())
+		case "esc":
+			// Back to the account that is still logged in
+			if m.accessToken != "" {
+				m.mode = ModeUserList
+				m.viewport.SetContent(m.renderContent())
+			}
 			return nil
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			return m.switchProfile(int(msg.String()[0] - '1'))
 		case "q", "ctrl+c":
 			return tea.Quit
 		}
@@ -35,12 +95,13 @@ func (m *model) handleKey(msg tea.KeyMsg) tea.Cmd {
 		case "enter":
 			if m.searchInput != "" {
 				m.searchMode = false
-				m.animeQuery = m.searchInput
+				// Keep any filters from the filter form in place
+				m.searchFilter.Search = m.searchInput
 				m.searchInput = ""
 				m.mode = ModeAnimeSearch
 				m.loading = true
 				m.loadingMsg = "Searching anime..."
-				return tea.Batch(m.spinner.Tick, performAnimeSearch(m.animeQuery, 1))
+				return tea.Batch(m.spinner.Tick, performAnimeSearch(m.listTracker(), m.searchFilter, 1))
 			}
 			m.searchMode = false
 			m.searchInput = ""
@@ -58,22 +119,121 @@ func (m *model) handleKey(msg tea.KeyMsg) tea.Cmd {
 		return nil
 	}
 
+	// Handle score input
+	if m.scoreMode {
+		switch msg.String() {
+		case "esc":
+			m.scoreMode = false
+			m.scoreInput = ""
+			return nil
+		case "enter":
+			m.scoreMode = false
+			score, err := m.scoreFormat.parse(m.scoreInput)
+			m.scoreInput = ""
+			if err != nil {
+				m.statusMsg = err.Error()
+				return nil
+			}
+			return m.editSelectedEntry(func(e *UserAnimeEntry) {
+				e.Score = score
+			})
+		case "backspace":
+			if len(m.scoreInput) > 0 {
+				m.scoreInput = m.scoreInput[:len(m.scoreInput)-1]
+			}
+			return nil
+		default:
+			if len(msg.String()) == 1 {
+				m.scoreInput += msg.String()
+			}
+		}
+		return nil
+	}
+
+	// Confirm deleting the selected entry
+	if m.pendingDelete != nil {
+		entry := *m.pendingDelete
+		m.pendingDelete = nil
+		if msg.String() != "y" {
+			m.statusMsg = ""
+			return nil
+		}
+		m.statusMsg = fmt.Sprintf("Deleting from %s...", m.listTracker().Name())
+		return deleteListEntry(m.listTracker(), entry)
+	}
+
+	// Handle the list entry editor
+	if m.entryEditor != nil {
+		return m.handleEntryEditorKeys(msg)
+	}
+
+	// Handle filter form
+	if m.filterForm != nil {
+		return m.handleFilterKeys(msg)
+	}
+
+	// Handle the import file prompt
+	if m.importForm != nil {
+		return m.handleImportFormKeys(msg)
+	}
+
 	// Common keys
 	switch msg.String() {
 	case "ctrl+c", "q":
 		return tea.Quit
+	case "?":
+		// Every key of the view in the footer
+		m.showKeys = !m.showKeys
+		return nil
 	case "esc":
 		if m.mode == ModeTorrents {
-			if m.accessToken != "" {
-				m.mode = ModeUserList
-			} else {
-				m.mode = ModeAnimeSearch
-			}
+			m.stopTorrentSearch()
+			m.mode = m.torrentReturnMode
 			m.selectedAnime = nil
 			m.viewport.SetContent(m.renderContent())
 			m.viewport.GotoTop()
 			return nil
-		} else if m.mode == ModeAnimeSearch && m.accessToken != "" {
+		} else if m.mode == ModeSchedule {
+			m.mode = m.scheduleReturnMode
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			return nil
+		} else if m.mode == ModeDetail {
+			m.mode = m.detailReturnMode
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			return nil
+		} else if m.mode == ModeNotifications {
+			m.mode = m.notificationReturnMode
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			return nil
+		} else if m.mode == ModeImport {
+			m.mode = m.importReturnMode
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			return nil
+		} else if m.mode == ModeStats {
+			m.mode = m.statsReturnMode
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			return nil
+		} else if m.mode == ModeSocial {
+			m.mode = m.socialReturnMode
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			return nil
+		} else if m.mode == ModeFranchise {
+			m.mode = m.franchiseReturnMode
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			return nil
+		} else if m.mode == ModeRecommendations {
+			m.popRecommendations()
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			return nil
+		} else if m.mode == ModeAnimeSearch {
 			m.mode = ModeUserList
 			m.viewport.SetContent(m.renderContent())
 			m.viewport.GotoTop()
@@ -86,36 +246,188 @@ func (m *model) handleKey(msg tea.KeyMsg) tea.Cmd {
 			m.searchInput = ""
 		}
 		return nil
+	case "+", "=":
+		// Increment progress
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			return m.editSelectedEntry(func(e *UserAnimeEntry) {
+				if e.Media.Episodes == nil || e.Progress < *e.Media.Episodes {
+					e.Progress++
+				}
+			})
+		}
+		return nil
+	case "-":
+		// Decrement progress
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			return m.editSelectedEntry(func(e *UserAnimeEntry) {
+				if e.Progress > 0 {
+					e.Progress--
+				}
+			})
+		}
+		return nil
+	case "c", "C":
+		// Cycle list status forward (c) or backward (C)
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			step := 1
+			if msg.String() == "C" {
+				step = -1
+			}
+			return m.editSelectedEntry(func(e *UserAnimeEntry) {
+				e.Status = nextStatus(e.Status, step)
+			})
+		}
+		return nil
+	case "x":
+		// Set score
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			m.scoreMode = true
+			m.scoreInput = ""
+		}
+		return nil
+	case "e":
+		// Edit every field of the selected entry
+		switch m.mode {
+		case ModeUserList, ModeAnimeSearch, ModeRecommendations, ModeFranchise, ModeSocial, ModeNotifications:
+			return m.openEntryEditor()
+		}
+		return nil
+	case "ctrl+d":
+		// Delete the selected entry, the next key confirms it
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			entry, ok := m.selectedEntry()
+			if !ok || entry.Status == "" {
+				return nil
+			}
+			m.pendingDelete = &entry
+			m.statusMsg = fmt.Sprintf("Delete %s from your list? y to confirm", m.animeTitle(entry.Media))
+		}
+		return nil
+	case "f":
+		// Open search filters
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			m.filterForm = newFilterForm(m.searchFilter)
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+		}
+		return nil
+	case "R":
+		// Recommendations for the selected anime
+		switch m.mode {
+		case ModeUserList, ModeAnimeSearch, ModeRecommendations, ModeFranchise, ModeSocial, ModeNotifications:
+			if entry, ok := m.selectedEntry(); ok {
+				return m.openRecommendations(entry.Media)
+			}
+		case ModeDetail:
+			if m.detail != nil {
+				return m.openRecommendations(m.detail.Media)
+			}
+		}
+		return nil
+	case "F":
+		// Franchise tree and watch order of the selected anime
+		switch m.mode {
+		case ModeUserList, ModeAnimeSearch, ModeRecommendations, ModeFranchise, ModeSocial, ModeNotifications:
+			if entry, ok := m.selectedEntry(); ok {
+				return m.openFranchise(entry.Media)
+			}
+		case ModeDetail:
+			if m.detail != nil {
+				return m.openFranchise(m.detail.Media)
+			}
+		}
+		return nil
+	case "i":
+		// Show full details for the selected anime
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch || m.mode == ModeRecommendations || m.mode == ModeFranchise || m.mode == ModeSocial || m.mode == ModeNotifications {
+			entry, ok := m.selectedEntry()
+			if !ok || !m.onAniList(entry.Media) {
+				return nil
+			}
+			m.selectedAnime = &entry.Media
+			m.detailReturnMode = m.mode
+			m.loading = true
+			m.loadingMsg = "Loading anime details..."
+			return tea.Batch(m.spinner.Tick, fetchAnimeDetail(m.aniList, entry.Media.ID))
+		}
+		return nil
+	case "a":
+		// Airing schedule
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			return m.openSchedule(false)
+		}
+		return nil
 	case "r":
 		// Refresh current list
-		if m.mode == ModeUserList && m.accessToken != "" {
+		if m.mode == ModeUserList {
 			m.loading = true
 			m.loadingMsg = "Refreshing list..."
-			return tea.Batch(m.spinner.Tick, m.fetchCurrentList())
+			return tea.Batch(m.spinner.Tick, m.refreshCurrentList())
+		} else if m.mode == ModeSocial {
+			return m.refreshActivities()
+		} else if m.mode == ModeNotifications {
+			return m.openNotifications()
 		}
 		return nil
-	case "tab":
-		// Cycle through list types
+	case "S":
+		// What the people the user follows are watching
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			return m.openSocial()
+		}
+		return nil
+	case "N":
+		// Notifications inbox
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			return m.openNotifications()
+		}
+		return nil
+	case "D":
+		// Statistics dashboard
+		if m.mode == ModeUserList || m.mode == ModeAnimeSearch {
+			return m.openStats()
+		}
+		return nil
+	case "E":
+		// Export the whole list as MyAnimeList XML and JSON
 		if m.mode == ModeUserList {
-			m.currentListType = (m.currentListType + 1) % 5
-			m.loading = true
-			m.loadingMsg = fmt.Sprintf("Loading %s...", m.currentListType.String())
-			return tea.Batch(m.spinner.Tick, m.fetchCurrentList())
+			return m.openExport()
+		}
+		return nil
+	case "I":
+		// Import a MyAnimeList export, or look at the running import again
+		if m.mode == ModeUserList {
+			m.openImport()
+		}
+		return nil
+	case "tab", "shift+tab":
+		// Cycle through list tabs
+		if m.mode == ModeUserList {
+			if msg.String() == "tab" {
+				return m.switchTab(1)
+			}
+			return m.switchTab(-1)
 		}
 		return nil
 	case "L":
 		// Logout (capital L)
 		if m.accessToken != "" {
-			homeDir, _ := os.UserHomeDir()
-			tokenPath := fmt.Sprintf("%s/%s", homeDir, tokenFile)
-			os.Remove(tokenPath)
-			m.accessToken = ""
-			m.username = ""
-			m.userID = 0
+			m.profiles.logout(m.trackerName, m.userID)
+			err := m.profiles.save()
+			m.endSession()
 			m.mode = ModeLogin
-			m.loginMsg = "Logged out. Press 'l' to login or 's' to browse"
+			m.loginMsg = "Logged out. Press 'l' to login, 'p' to paste a token or 's' to browse"
+			if err != nil {
+				// The login screen shows loginMsg, not the status
+				m.statusMsg = fmt.Sprintf("Logged out, but failed to remove the saved token: %v", err)
+				m.loginMsg = m.statusMsg
+			}
 		}
 		return nil
+	case "P":
+		// Pick another profile, the current one stays logged in
+		m.mode = ModeLogin
+		m.loginMsg = "Pick a profile, press 'l' or 'p' to add an account or Esc to go back"
+		return nil
 	}
 
 	// Mode-specific keys
@@ -126,11 +438,131 @@ func (m *model) handleKey(msg tea.KeyMsg) tea.Cmd {
 		return m.handleAnimeKeys(msg)
 	case ModeTorrents:
 		return m.handleTorrentKeys(msg)
+	case ModeDetail:
+		return m.handleDetailKeys(msg)
+	case ModeSchedule:
+		return m.handleScheduleKeys(msg)
+	case ModeRecommendations:
+		return m.handleRecommendationKeys(msg)
+	case ModeFranchise:
+		return m.handleFranchiseKeys(msg)
+	case ModeSocial:
+		return m.handleSocialKeys(msg)
+	case ModeNotifications:
+		return m.handleNotificationKeys(msg)
+	case ModeImport:
+		return m.handleImportKeys(msg)
 	}
 
 	return nil
 }
 
+// switchProfile logs in with the saved profile at index i
+func (m *model) switchProfile(i int) tea.Cmd {
+	if i >= len(m.profiles.Profiles) {
+		return nil
+	}
+	return m.loginProfile(m.profiles.Profiles[i])
+}
+
+func (m *model) loginProfile(p profile) tea.Cmd {
+	if !p.LoggedIn() {
+		m.loginMsg = fmt.Sprintf("%s is logged out, press 'l' or 'p' to login again", p.Username)
+		return nil
+	}
+	m.loading = true
+	m.loadingMsg = fmt.Sprintf("Switching to %s...", p.Username)
+	return tea.Batch(m.spinner.Tick, loginWithToken(newTracker(p.Tracker, m.aniList, m.mal), p.token()))
+}
+
+// newSecretInput is a masked text input for tokens and passphrases
+func newSecretInput(placeholder string) textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.EchoMode = textinput.EchoPassword
+	ti.Width = 40
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	ti.Focus()
+	return ti
+}
+
+func (m *model) askPassphrase(prompt passphrasePrompt) {
+	m.passPrompt = prompt
+	m.passInput = newSecretInput("")
+}
+
+// handlePassphraseKeys unlocks the profiles or sets a new passphrase
+func (m *model) handlePassphraseKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.passPrompt = promptNone
+		m.newPassphrase = ""
+		return nil
+	case "ctrl+c":
+		return tea.Quit
+	case "enter":
+		passphrase := m.passInput.Value()
+		switch m.passPrompt {
+		case promptUnlock:
+			if err := m.profiles.unlock(passphrase); err != nil {
+				m.loginMsg = fmt.Sprintf("Unlock failed: %v", err)
+				m.passInput.Reset()
+				return nil
+			}
+			m.passPrompt = promptNone
+			m.loginMsg = "Profiles unlocked. Pick a profile, press 'l' or 'p' to add an account or 's' to browse"
+			if p := m.profiles.active(); p != nil && p.LoggedIn() {
+				return m.loginProfile(*p)
+			}
+		case promptNew:
+			m.newPassphrase = passphrase
+			m.askPassphrase(promptConfirm)
+		case promptConfirm:
+			m.passPrompt = promptNone
+			if passphrase != m.newPassphrase {
+				m.newPassphrase = ""
+				m.loginMsg = "Passphrases don't match, press 'e' to try again"
+				return nil
+			}
+			m.newPassphrase = ""
+			if err := m.profiles.setPassphrase(passphrase); err != nil {
+				m.loginMsg = fmt.Sprintf("Failed to save profiles: %v", err)
+			} else if passphrase == "" {
+				m.loginMsg = "Passphrase removed, tokens are saved unencrypted"
+			} else {
+				m.loginMsg = "Tokens are now encrypted with your passphrase"
+			}
+		}
+		return nil
+	}
+	var cmd tea.Cmd
+	m.passInput, cmd = m.passInput.Update(msg)
+	return cmd
+}
+
+// handlePinKeys edits the pasted token of the PIN login
+func (m *model) handlePinKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.pinMode = false
+		return nil
+	case "ctrl+c":
+		return tea.Quit
+	case "enter":
+		token := strings.TrimSpace(m.pinInput.Value())
+		if token == "" {
+			return nil
+		}
+		m.pinMode = false
+		m.loading = true
+		m.loadingMsg = "Checking token..."
+		return tea.Batch(m.spinner.Tick, loginWithToken(&aniListTracker{client: m.aniList}, authToken{AccessToken: token}))
+	}
+	var cmd tea.Cmd
+	m.pinInput, cmd = m.pinInput.Update(msg)
+	return cmd
+}
+
 func (m *model) handleUserListKeys(msg tea.KeyMsg) tea.Cmd {
 	switch msg.String() {
 	case "up", "k":
@@ -145,17 +577,14 @@ func (m *model) handleUserListKeys(msg tea.KeyMsg) tea.Cmd {
 			m.viewport.SetContent(m.renderContent())
 			m.ensureCursorVisible(4)
 		}
+		return m.loadMoreIfAtEnd()
 	case "enter":
 		if m.userEntryCursor < len(m.userEntries) {
 			entry := m.userEntries[m.userEntryCursor]
 			m.selectedAnime = &entry.Media
-			title := entry.Media.Title.English
-			if title == "" {
-				title = entry.Media.Title.Romaji
-			}
 			m.loading = true
-			m.loadingMsg = "looking for torrets..."
-			return tea.Batch(m.spinner.Tick, performTorrentSearch(title))
+			m.loadingMsg = "looking for torrents..."
+			return tea.Batch(m.spinner.Tick, m.searchTorrents(searchTitles(entry.Media)...))
 		}
 	}
 	return nil
@@ -165,11 +594,11 @@ func (m *model) handleAnimeKeys(msg tea.KeyMsg) tea.Cmd {
 	switch msg.String() {
 	case "n":
 		if m.animePage < m.animeTotalPages-1 {
-			return performAnimeSearch(m.animeQuery, m.animePage+2)
+			return performAnimeSearch(m.listTracker(), m.searchFilter, m.animePage+2)
 		}
 	case "p":
 		if m.animePage > 0 {
-			return performAnimeSearch(m.animeQuery, m.animePage)
+			return performAnimeSearch(m.listTracker(), m.searchFilter, m.animePage)
 		}
 	case "up", "k":
 		if m.animeCursor > 0 {
@@ -186,11 +615,20 @@ func (m *model) handleAnimeKeys(msg tea.KeyMsg) tea.Cmd {
 	case "enter":
 		if m.animeCursor < len(m.anime) {
 			m.selectedAnime = &m.anime[m.animeCursor]
-			title := m.selectedAnime.Title.English
-			if title == "" {
-				title = m.selectedAnime.Title.Romaji
-			}
-			return performTorrentSearch(title)
+			return m.searchTorrents(searchTitles(*m.selectedAnime)...)
+		}
+	}
+	return nil
+}
+
+func (m *model) handleDetailKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		if m.detail != nil {
+			m.selectedAnime = &m.detail.Media
+			m.loading = true
+			m.loadingMsg = "looking for torrents..."
+			return tea.Batch(m.spinner.Tick, m.searchTorrents(searchTitles(m.detail.Media)...))
 		}
 	}
 	return nil
@@ -257,6 +695,24 @@ func (m *model) ensureCursorVisible(lineHeight int) {
 		cursorY = m.animeCursor * lineHeight
 	case ModeTorrents:
 		cursorY = m.torrentCursor * lineHeight
+	case ModeSchedule:
+		cursorY = m.scheduleCursorLine() * lineHeight
+	case ModeRecommendations:
+		if level := m.currentRecommendations(); level != nil {
+			cursorY = (level.cursor + 2) * lineHeight
+		}
+	case ModeFranchise:
+		if m.franchise != nil {
+			cursorY = (m.franchise.cursor + 2) * lineHeight
+		}
+	case ModeSocial:
+		cursorY = (m.activityCursor + 2) * lineHeight
+	case ModeNotifications:
+		cursorY = m.notificationCursor * lineHeight
+	case ModeImport:
+		if m.listImport != nil {
+			cursorY = (m.listImport.cursor + 3) * lineHeight
+		}
 	}
 
 	if cursorY < m.viewport.YOffset {
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// selectedEntry returns the list entry under the cursor in the current view
+func (m *model) selectedEntry() (UserAnimeEntry, bool) {
+	switch m.mode {
+	case ModeUserList:
+		if m.userEntryCursor < len(m.userEntries) {
+			return m.userEntries[m.userEntryCursor], true
+		}
+	case ModeAnimeSearch:
+		if m.animeCursor < len(m.anime) {
+			return entryFromMedia(m.anime[m.animeCursor]), true
+		}
+	case ModeRecommendations:
+		if anime, ok := m.selectedRecommendation(); ok {
+			return entryFromMedia(*anime), true
+		}
+	case ModeFranchise:
+		if m.franchise != nil {
+			if n := m.franchise.selected(); n != nil {
+				return entryFromMedia(n.Media), true
+			}
+		}
+	case ModeSocial:
+		if a, ok := m.selectedActivity(); ok {
+			return entryFromMedia(*a.Media), true
+		}
+	case ModeNotifications:
+		if n, ok := m.selectedNotification(); ok && n.Media != nil {
+			return entryFromMedia(*n.Media), true
+		}
+	}
+	return UserAnimeEntry{}, false
+}
+
+// findEntry looks up the loaded list entry for a media ID
+func (m *model) findEntry(mediaID int) (UserAnimeEntry, bool) {
+	for _, e := range m.userEntries {
+		if e.Media.ID == mediaID {
+			return e, true
+		}
+	}
+	for _, a := range m.anime {
+		if a.ID == mediaID {
+			return entryFromMedia(a), true
+		}
+	}
+	return UserAnimeEntry{}, false
+}
+
+// editSelectedEntry applies edit to the entry under the cursor and saves it
+func (m *model) editSelectedEntry(edit func(*UserAnimeEntry)) tea.Cmd {
+	prev, ok := m.selectedEntry()
+	if !ok {
+		return nil
+	}
+	prev = m.overlayLocal(prev)[0]
+
+	next := prev
+	edit(&next)
+	return m.updateListEntry(prev, next)
+}
+
+// updateListEntry shows next immediately and sends it to the tracker. The view is
+// rolled back to prev when the mutation fails.
+func (m *model) updateListEntry(prev, next UserAnimeEntry) tea.Cmd {
+	if next.Status == "" {
+		next.Status = "CURRENT"
+	}
+	if next.Status == prev.Status && next.Progress == prev.Progress && next.Score == prev.Score {
+		return nil
+	}
+
+	m.storeEntry(next)
+	m.statusMsg = fmt.Sprintf("Saving to %s...", m.listTracker().Name())
+	if m.ready {
+		m.viewport.SetContent(m.renderContent())
+	}
+	return saveMediaListEntry(m.listTracker(), m.nextEntrySave(next.Media.ID), prev, next)
+}
+
+// nextEntrySave numbers a new save of the anime's list entry. Saves run at
+// the same time and can be answered in any order, only the answer to the
+// latest one is applied.
+func (m *model) nextEntrySave(mediaID int) int {
+	if m.entrySaves == nil {
+		m.entrySaves = map[int]int{}
+	}
+	m.entrySaves[mediaID]++
+	return m.entrySaves[mediaID]
+}
+
+// planToWatch adds an anime that isn't on the user's lists yet to PLANNING
+func (m *model) planToWatch(anime Anime) tea.Cmd {
+	prev := m.overlayLocal(entryFromMedia(anime))[0]
+	if prev.Status != "" {
+		m.statusMsg = fmt.Sprintf("%s is already on your %s list", m.animeTitle(anime), prev.Status)
+		m.viewport.SetContent(m.renderContent())
+		return nil
+	}
+	next := prev
+	next.Status = "PLANNING"
+	return m.updateListEntry(prev, next)
+}
+
+// storeEntry writes the list fields of entry into every loaded copy of its media
+func (m *model) storeEntry(entry UserAnimeEntry) {
+	var listEntry *MediaListEntry
+	if entry.Status != "" {
+		listEntry = &MediaListEntry{
+			ID:       entry.ID,
+			Status:   entry.Status,
+			Progress: entry.Progress,
+			Score:    entry.Score,
+		}
+	}
+
+	for i := range m.userEntries {
+		if m.userEntries[i].Media.ID == entry.Media.ID {
+			updateEntryFields(&m.userEntries[i], entry)
+		}
+	}
+	m.storeCollectionEntry(entry)
+
+	for i := range m.anime {
+		if m.anime[i].ID == entry.Media.ID {
+			m.anime[i].MediaListEntry = listEntry
+		}
+	}
+
+	for _, level := range m.recStack {
+		for _, rec := range level.items {
+			if rec.Media.ID == entry.Media.ID {
+				rec.Media.MediaListEntry = listEntry
+			}
+		}
+	}
+
+	for _, a := range m.activities {
+		if a.Media.ID == entry.Media.ID {
+			a.Media.MediaListEntry = listEntry
+		}
+	}
+
+	if m.franchise != nil {
+		if n := m.franchise.nodes[entry.Media.ID]; n != nil {
+			n.MediaListEntry = listEntry
+		}
+	}
+
+	if m.selectedAnime != nil && m.selectedAnime.ID == entry.Media.ID {
+		m.selectedAnime.MediaListEntry = listEntry
+	}
+}
+
+// updateEntryFields copies the list fields of entry into e
+func updateEntryFields(e *UserAnimeEntry, entry UserAnimeEntry) {
+	e.ID = entry.ID
+	e.Status = entry.Status
+	e.Progress = entry.Progress
+	e.Score = entry.Score
+	if entry.UpdatedAt > 0 {
+		e.UpdatedAt = entry.UpdatedAt
+	}
+	e.Media.MediaListEntry = nil
+	if entry.Status != "" {
+		e.Media.MediaListEntry = &MediaListEntry{
+			ID:       entry.ID,
+			Status:   entry.Status,
+			Progress: entry.Progress,
+			Score:    entry.Score,
+		}
+	}
+}
+
+// nextStatus returns the status step positions away from current in listStatuses
+func nextStatus(current string, step int) string {
+	idx := -1
+	for i, s := range listStatuses {
+		if s == current {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		if step > 0 {
+			return listStatuses[0]
+		}
+		return listStatuses[len(listStatuses)-1]
+	}
+	n := len(listStatuses)
+	return listStatuses[((idx+step)%n+n)%n]
+}
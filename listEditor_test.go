@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListEntrySavedIgnoresStaleAnswers(t *testing.T) {
+	anime := Anime{ID: 21}
+	start := UserAnimeEntry{Media: anime, Status: "CURRENT", Progress: 4}
+	m := &model{mode: ModeUserList, userEntries: []UserAnimeEntry{start}}
+
+	first := start
+	first.Progress = 5
+	m.updateListEntry(start, first)
+	second := first
+	second.Progress = 6
+	m.updateListEntry(first, second)
+
+	// The first save is answered last
+	m.Update(listEntrySavedMsg{seq: 2, prev: first, entry: second})
+	m.Update(listEntrySavedMsg{seq: 1, prev: start, entry: first})
+	if got := m.userEntries[0].Progress; got != 6 {
+		t.Errorf("progress %d after a stale answer, want 6", got)
+	}
+
+	// A failed older save doesn't roll back the newer edit
+	third := second
+	third.Progress = 7
+	m.updateListEntry(second, third)
+	fourth := third
+	fourth.Progress = 8
+	m.updateListEntry(third, fourth)
+	m.Update(listEntrySavedMsg{seq: 3, prev: second, entry: third, err: errors.New("rate limited")})
+	if got := m.userEntries[0].Progress; got != 8 {
+		t.Errorf("progress %d after an older save failed, want 8", got)
+	}
+
+	// The latest failing rolls back to what it replaced
+	m.Update(listEntrySavedMsg{seq: 4, prev: third, entry: fourth, err: errors.New("rate limited")})
+	if got := m.userEntries[0].Progress; got != 7 {
+		t.Errorf("progress %d after the latest save failed, want 7", got)
+	}
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+// Entries saved per batch of an import, and the pause between batches that
+// leaves room in AniList's rate limit for the rest of the app
+const (
+	importBatchSize  = 10
+	importBatchPause = 5 * time.Second
+)
+
+// MyAnimeList IDs looked up per request when planning an import, one page of
+// AniList results
+const importLookupSize = 50
+
+// importChange is one anime of an import with the entry it will be saved as
+type importChange struct {
+	prev  *UserAnimeEntry // nil when the anime isn't on the list yet
+	entry UserAnimeEntry
+	diffs []string
+}
+
+// importFailure is an entry AniList refused to save
+type importFailure struct {
+	media Anime
+	err   error
+}
+
+// listImport is the dry run of a MyAnimeList import and, once confirmed,
+// its progress
+type listImport struct {
+	path      string
+	session   int
+	changes   []importChange
+	unchanged int
+	unmatched []malAnime
+	cursor    int
+	applying  bool
+	applied   int
+	failed    []importFailure
+}
+
+type listExportedMsg struct {
+	path    string
+	count   int
+	skipped int
+	err     error
+}
+
+type importPlanMsg struct {
+	plan *listImport
+	err  error
+}
+
+type importBatchMsg struct {
+	session int
+	count   int
+	saved   []UserAnimeEntry
+	failed  []importFailure
+}
+
+// importNextBatchMsg starts the next batch after the pause
+type importNextBatchMsg struct {
+	session int
+}
+
+func exportList(client *anilist.Client, userID int, username string, scores scoreFormat) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		entries, err := client.WithCachePolicy(anilist.NetworkFirst).MediaListCollectionDetails(ctx, userID)
+		if err != nil {
+			return listExportedMsg{err: err}
+		}
+		path, skipped, err := writeListExport(exportDir(), username, entries, scores)
+		return listExportedMsg{path: path, count: len(entries) - skipped, skipped: skipped, err: err}
+	}
+}
+
+// mediaByMalIDs looks up anime by MyAnimeList ID a page at a time. A whole
+// export can take minutes under AniList's rate limit, so each page gets its
+// own timeout.
+func mediaByMalIDs(client *anilist.Client, ids []int) ([]Anime, error) {
+	var media []Anime
+	for start := 0; start < len(ids); start += importLookupSize {
+		ctx, cancel := aniListContext()
+		page, err := client.MediaByMalIDs(ctx, ids[start:min(start+importLookupSize, len(ids))])
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		media = append(media, page...)
+	}
+	return media, nil
+}
+
+// planImport compares a MyAnimeList export with the user's list without
+// changing anything
+func planImport(client *anilist.Client, userID, session int, path string, scores scoreFormat) tea.Cmd {
+	return func() tea.Msg {
+		list, err := readMalList(path)
+		if err != nil {
+			return importPlanMsg{err: err}
+		}
+
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		entries, err := client.WithCachePolicy(anilist.NetworkFirst).MediaListCollectionDetails(ctx, userID)
+		if err != nil {
+			return importPlanMsg{err: err}
+		}
+		onList := map[int]UserAnimeEntry{}
+		for _, e := range entries {
+			onList[e.Media.ID] = e
+		}
+
+		var ids []int
+		for _, a := range list.Anime {
+			ids = append(ids, a.ID)
+		}
+		media, err := mediaByMalIDs(client, ids)
+		if err != nil {
+			return importPlanMsg{err: err}
+		}
+		byMalID := map[int]Anime{}
+		for _, a := range media {
+			byMalID[a.IDMal] = a
+		}
+
+		plan := &listImport{path: path, session: session}
+		for _, a := range list.Anime {
+			anime, ok := byMalID[a.ID]
+			if !ok {
+				plan.unmatched = append(plan.unmatched, a)
+				continue
+			}
+			var prev *UserAnimeEntry
+			if e, ok := onList[anime.ID]; ok {
+				prev = &e
+			}
+			change := newImportChange(a, anime, prev, scores)
+			if prev != nil && len(change.diffs) == 0 {
+				plan.unchanged++
+				continue
+			}
+			plan.changes = append(plan.changes, change)
+		}
+		return importPlanMsg{plan: plan}
+	}
+}
+
+// newImportChange applies a MyAnimeList entry on top of the AniList one.
+// Fields the export leaves empty keep their AniList value.
+func newImportChange(a malAnime, anime Anime, prev *UserAnimeEntry, scores scoreFormat) importChange {
+	entry := UserAnimeEntry{Media: anime}
+	before := UserAnimeEntry{}
+	if prev != nil {
+		entry = *prev
+		before = *prev
+	}
+
+	entry.Status = aniListStatus(a)
+	entry.Progress = a.WatchedEpisodes
+	if eps := anime.Episodes; eps != nil && entry.Progress > *eps {
+		entry.Progress = *eps
+	}
+	// Scores only change when they differ on MyAnimeList's coarser scale
+	if a.Score > 0 && scores.toTen(entry.Score) != a.Score {
+		entry.Score = scores.fromTen(a.Score)
+	}
+	entry.Repeat = a.TimesWatched
+	if d := parseMalDate(a.StartDate); d.Year != nil {
+		entry.StartedAt = d
+	}
+	if d := parseMalDate(a.FinishDate); d.Year != nil {
+		entry.CompletedAt = d
+	}
+	if notes := strings.TrimSpace(a.Comments); notes != "" {
+		entry.Notes = notes
+	}
+
+	var diffs []string
+	field := func(name, from, to string) {
+		if from == "" {
+			from = "–"
+		}
+		if from != to && to != "" {
+			diffs = append(diffs, fmt.Sprintf("%s %s → %s", name, from, to))
+		}
+	}
+	field("status", listStatusText(before.Status), listStatusText(entry.Status))
+	field("progress", strconv.Itoa(before.Progress), strconv.Itoa(entry.Progress))
+	if before.Score != entry.Score {
+		field("score", scoreText(scores, before.Score), scoreText(scores, entry.Score))
+	}
+	field("rewatches", strconv.Itoa(before.Repeat), strconv.Itoa(entry.Repeat))
+	field("started", fuzzyDateInput(before.StartedAt), fuzzyDateInput(entry.StartedAt))
+	field("completed", fuzzyDateInput(before.CompletedAt), fuzzyDateInput(entry.CompletedAt))
+	if before.Notes != entry.Notes {
+		diffs = append(diffs, "notes")
+	}
+	return importChange{prev: prev, entry: entry, diffs: diffs}
+}
+
+func scoreText(scores scoreFormat, score float64) string {
+	if score == 0 {
+		return "none"
+	}
+	return scores.format(score)
+}
+
+func applyImportBatch(client *anilist.Client, session int, changes []importChange) tea.Cmd {
+	return func() tea.Msg {
+		msg := importBatchMsg{session: session, count: len(changes)}
+		for _, c := range changes {
+			e := c.entry
+			ctx, cancel := aniListContext()
+			saved, err := client.SaveMediaListEntry(ctx, anilist.SaveMediaListEntryInput{
+				MediaID:     e.Media.ID,
+				Status:      e.Status,
+				Progress:    e.Progress,
+				Score:       e.Score,
+				Repeat:      &e.Repeat,
+				Notes:       &e.Notes,
+				StartedAt:   &e.StartedAt,
+				CompletedAt: &e.CompletedAt,
+			})
+			cancel()
+			if err != nil {
+				msg.failed = append(msg.failed, importFailure{media: e.Media, err: err})
+				continue
+			}
+			saved.Media = e.Media
+			msg.saved = append(msg.saved, *saved)
+		}
+		return msg
+	}
+}
+
+// openExport writes the whole list to the export directory
+func (m *model) openExport() tea.Cmd {
+	if !m.requireAniList("export your list") {
+		return nil
+	}
+	m.loading = true
+	m.loadingMsg = "Exporting your list..."
+	return tea.Batch(m.spinner.Tick, exportList(m.aniList, m.userID, m.username, m.scoreFormat))
+}
+
+// openImport asks for the MyAnimeList export to import
+func (m *model) openImport() {
+	if !m.requireAniList("import a list") {
+		return
+	}
+	// Only one import runs at a time, show its progress instead
+	if m.listImport != nil && m.listImport.applying {
+		m.importReturnMode = m.mode
+		m.mode = ModeImport
+		m.viewport.SetContent(m.renderContent())
+		m.ensureCursorVisible(1)
+		return
+	}
+	m.importForm = newForm(textField("MAL export", exportDir()+string(filepath.Separator), ".xml or .xml.gz from MyAnimeList"))
+	m.viewport.SetContent(m.renderContent())
+	m.viewport.GotoTop()
+}
+
+func (m *model) handleImportFormKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.importForm = nil
+	case "enter":
+		path := expandHome(m.importForm.value(0))
+		m.importForm = nil
+		m.loading = true
+		m.loadingMsg = "Comparing the export with your list..."
+		return tea.Batch(m.spinner.Tick, planImport(m.aniList, m.userID, m.session, path, m.scoreFormat))
+	default:
+		m.importForm.update(msg)
+	}
+	m.viewport.SetContent(m.renderContent())
+	return nil
+}
+
+func (m *model) setImportPlan(msg importPlanMsg) {
+	m.loading = false
+	if msg.err != nil {
+		m.statusMsg = fmt.Sprintf("Import failed: %v", msg.err)
+		return
+	}
+	if msg.plan.session != m.session {
+		return
+	}
+	if m.mode != ModeImport {
+		m.importReturnMode = m.mode
+	}
+	m.listImport = msg.plan
+	m.mode = ModeImport
+}
+
+// nextImportBatch saves the next batch of the confirmed import
+func (m *model) nextImportBatch() tea.Cmd {
+	p := m.listImport
+	end := min(p.applied+importBatchSize, len(p.changes))
+	m.statusMsg = fmt.Sprintf("Importing %d/%d...", p.applied, len(p.changes))
+	return applyImportBatch(m.aniList, p.session, p.changes[p.applied:end])
+}
+
+func (m *model) setImportBatch(msg importBatchMsg) tea.Cmd {
+	p := m.listImport
+	if p == nil || msg.session != p.session || msg.session != m.session {
+		return nil
+	}
+	p.applied += msg.count
+	p.failed = append(p.failed, msg.failed...)
+	for _, e := range msg.saved {
+		m.storeEntry(e)
+	}
+
+	if p.applied < len(p.changes) {
+		m.statusMsg = fmt.Sprintf("Importing %d/%d...", p.applied, len(p.changes))
+		session := p.session
+		return tea.Tick(importBatchPause, func(time.Time) tea.Msg {
+			return importNextBatchMsg{session: session}
+		})
+	}
+	p.applying = false
+	m.statusMsg = fmt.Sprintf("Imported %d anime", p.applied-len(p.failed))
+	if len(p.failed) > 0 {
+		m.statusMsg += fmt.Sprintf(", %d failed", len(p.failed))
+	}
+	return nil
+}
+
+func (m *model) handleImportKeys(msg tea.KeyMsg) tea.Cmd {
+	p := m.listImport
+	switch msg.String() {
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+		}
+	case "down", "j":
+		if p.cursor < len(p.changes)-1 {
+			p.cursor++
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+		}
+	case "enter":
+		if p.applying || p.applied > 0 || len(p.changes) == 0 {
+			return nil
+		}
+		p.applying = true
+		cmd := m.nextImportBatch()
+		m.viewport.SetContent(m.renderContent())
+		return cmd
+	}
+	return nil
+}
+
+func (m *model) renderImportForm() string {
+	var sb strings.Builder
+	sb.WriteString("📥 Import a MyAnimeList export\n\n")
+	sb.WriteString(m.importForm.view())
+	sb.WriteString("\nExport your list on MyAnimeList under Profile → Export Lists.\n")
+	return sb.String()
+}
+
+func (m *model) renderImportContent() string {
+	p := m.listImport
+	if p == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	added := 0
+	for _, c := range p.changes {
+		if c.prev == nil {
+			added++
+		}
+	}
+	sb.WriteString(fmt.Sprintf("📥 %s: %d to add, %d to update, %d unchanged, %d not on AniList\n",
+		filepath.Base(p.path), added, len(p.changes)-added, p.unchanged, len(p.unmatched)))
+	switch {
+	case len(p.changes) == 0:
+		sb.WriteString("Nothing to import, your list is up to date.\n")
+	case p.applying:
+		sb.WriteString(fmt.Sprintf("Importing %d/%d...\n", p.applied, len(p.changes)))
+	case p.applied > 0:
+		sb.WriteString(fmt.Sprintf("✓ Imported %d, %d failed\n", p.applied-len(p.failed), len(p.failed)))
+	default:
+		sb.WriteString("Dry run, nothing was changed yet. Press Enter to apply.\n")
+	}
+	sb.WriteString("\n")
+
+	width := max(20, m.viewport.Width-8)
+	for i, c := range p.changes {
+		cursor := "  "
+		if i == p.cursor {
+			cursor = "▶ "
+		}
+		e := c.entry
+		var text string
+		if c.prev == nil {
+			text = fmt.Sprintf("+ %s | %s", m.animeTitle(e.Media), listStatusText(e.Status))
+			if e.Progress > 0 {
+				text += fmt.Sprintf(" | Ep %d", e.Progress)
+			}
+			if e.Score > 0 {
+				text += " | " + m.scoreFormat.format(e.Score)
+			}
+		} else {
+			text = fmt.Sprintf("~ %s | %s", m.animeTitle(e.Media), strings.Join(c.diffs, ", "))
+		}
+		sb.WriteString(cursor + truncateToWidth(text, width) + "\n")
+	}
+
+	if len(p.failed) > 0 {
+		sb.WriteString("\n⚠ Failed\n")
+		for _, f := range p.failed {
+			sb.WriteString(truncateToWidth(fmt.Sprintf("  %s: %v", m.animeTitle(f.media), f.err), width) + "\n")
+		}
+	}
+	if len(p.unmatched) > 0 {
+		sb.WriteString("\n❓ Not found on AniList\n")
+		for _, a := range p.unmatched {
+			sb.WriteString(fmt.Sprintf("  %s (MAL %d)\n", a.Title, a.ID))
+		}
+	}
+	return sb.String()
+}
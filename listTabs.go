@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+var activeTabStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+
+// listTab is one tab of ModeUserList
+type listTab struct {
+	listType ListType
+	status   string // status of a personal status list
+	name     string // name of a custom list
+}
+
+var statusTabs = []listTab{
+	{listType: ListCurrentlyWatching, status: "CURRENT"},
+	{listType: ListPlanToWatch, status: "PLANNING"},
+	{listType: ListCompleted, status: "COMPLETED"},
+	{listType: ListPaused, status: "PAUSED"},
+	{listType: ListDropped, status: "DROPPED"},
+	{listType: ListRepeating, status: "REPEATING"},
+}
+
+var browseTabs = []listTab{
+	{listType: ListTrending},
+	{listType: ListPopularSeason},
+	{listType: ListTopRated},
+}
+
+func (t listTab) title() string {
+	if t.listType == ListCustom {
+		return t.name
+	}
+	return t.listType.String()
+}
+
+// collectionMsg carries every list of the user's MediaListCollection
+type collectionMsg struct {
+	groups  []anilist.MediaListGroup
+	refresh bool // a background refresh, it never changes the view or the loading state
+}
+
+func fetchUserCollection(tracker Tracker, userID int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		groups, err := tracker.ListCollection(ctx, userID)
+		if err != nil {
+			return aniListErrorMsg{err: err}
+		}
+		return collectionMsg{groups: groups}
+	}
+}
+
+// listTabs returns the status lists, the user's custom lists and the browse
+// lists, in tab order
+func (m *model) listTabs() []listTab {
+	tabs := append([]listTab{}, statusTabs...)
+	for _, g := range m.collection {
+		if g.IsCustomList {
+			tabs = append(tabs, listTab{listType: ListCustom, name: g.Name})
+		}
+	}
+	return append(tabs, browseTabs...)
+}
+
+func (m *model) currentTab() listTab {
+	tabs := m.listTabs()
+	if m.listTabIndex >= len(tabs) {
+		m.listTabIndex = 0
+	}
+	return tabs[m.listTabIndex]
+}
+
+// tabEntries returns the entries of a personal tab from the loaded collection
+func (m *model) tabEntries(tab listTab) []UserAnimeEntry {
+	var entries []UserAnimeEntry
+	for _, g := range m.collection {
+		if tab.listType == ListCustom {
+			if g.IsCustomList && g.Name == tab.name {
+				entries = append(entries, g.Entries...)
+			}
+		} else if !g.IsCustomList && g.Status == tab.status {
+			entries = append(entries, g.Entries...)
+		}
+	}
+	return entries
+}
+
+// switchTab moves step tabs along and shows that list
+func (m *model) switchTab(step int) tea.Cmd {
+	tabs := m.listTabs()
+	m.listTabIndex = ((m.listTabIndex+step)%len(tabs) + len(tabs)) % len(tabs)
+	m.userEntryCursor = 0
+	return m.showTab()
+}
+
+// showTab shows the current tab. Personal lists come from the loaded
+// collection, everything else is fetched from AniList.
+func (m *model) showTab() tea.Cmd {
+	tab := m.currentTab()
+	m.currentListType = tab.listType
+	m.currentCustomList = tab.name
+
+	if tab.listType.Personal() && m.collection != nil {
+		m.mode = ModeUserList
+		m.userEntries = m.tabEntries(tab)
+		m.listPage = 1
+		m.listHasNextPage = false
+		if m.userEntryCursor >= len(m.userEntries) {
+			m.userEntryCursor = max(0, len(m.userEntries)-1)
+		}
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			if m.userEntryCursor == 0 {
+				m.viewport.GotoTop()
+			}
+		}
+		return nil
+	}
+
+	m.loading = true
+	m.loadingMsg = fmt.Sprintf("Loading %s...", tab.title())
+	return tea.Batch(m.spinner.Tick, m.fetchCurrentList())
+}
+
+// setCollection replaces the loaded collection, keeping the current tab
+// selected even if custom lists were added or removed
+func (m *model) setCollection(groups []anilist.MediaListGroup) {
+	current := m.currentTab()
+	m.collection = groups
+	m.listTabIndex = 0
+	for i, tab := range m.listTabs() {
+		if tab == current {
+			m.listTabIndex = i
+			break
+		}
+	}
+}
+
+// storeCollectionEntry mirrors an entry update into the loaded collection,
+// moving it to the status list that matches its new status. Entries without
+// a status are removed from every list.
+func (m *model) storeCollectionEntry(entry UserAnimeEntry) {
+	var existing *UserAnimeEntry
+	for gi := range m.collection {
+		g := &m.collection[gi]
+		for i := 0; i < len(g.Entries); i++ {
+			if g.Entries[i].Media.ID != entry.Media.ID {
+				continue
+			}
+			if g.IsCustomList && entry.Status != "" {
+				updateEntryFields(&g.Entries[i], entry)
+				continue
+			}
+			if existing == nil && !g.IsCustomList {
+				e := g.Entries[i]
+				existing = &e
+			}
+			g.Entries = append(g.Entries[:i], g.Entries[i+1:]...)
+			i--
+		}
+	}
+
+	if entry.Status == "" {
+		return
+	}
+
+	moved := entry
+	if existing != nil {
+		moved = *existing
+		updateEntryFields(&moved, entry)
+	}
+	for gi := range m.collection {
+		g := &m.collection[gi]
+		if !g.IsCustomList && g.Status == entry.Status {
+			g.Entries = append([]UserAnimeEntry{moved}, g.Entries...)
+			return
+		}
+	}
+	m.collection = append(m.collection, anilist.MediaListGroup{
+		Name:    entry.Status,
+		Status:  entry.Status,
+		Entries: []UserAnimeEntry{moved},
+	})
+}
+
+// storeCustomLists adds the entry to or removes it from the loaded custom
+// lists. Entries that don't carry their custom lists are left alone.
+func (m *model) storeCustomLists(entry UserAnimeEntry) {
+	if entry.CustomLists == nil {
+		return
+	}
+	enabled := map[string]bool{}
+	for _, l := range entry.CustomLists {
+		enabled[l.Name] = l.Enabled
+	}
+	for gi := range m.collection {
+		g := &m.collection[gi]
+		if !g.IsCustomList {
+			continue
+		}
+		found := -1
+		for i, e := range g.Entries {
+			if e.Media.ID == entry.Media.ID {
+				found = i
+			}
+		}
+		switch {
+		case enabled[g.Name] && found < 0:
+			g.Entries = append([]UserAnimeEntry{entry}, g.Entries...)
+		case !enabled[g.Name] && found >= 0:
+			g.Entries = append(g.Entries[:found], g.Entries[found+1:]...)
+		}
+	}
+}
+
+// customListMembership returns which of the named custom lists the loaded
+// collection has the anime on
+func (m *model) customListMembership(mediaID int, names []string) []anilist.CustomListMembership {
+	onList := map[string]bool{}
+	for _, g := range m.collection {
+		if !g.IsCustomList {
+			continue
+		}
+		for _, e := range g.Entries {
+			if e.Media.ID == mediaID {
+				onList[g.Name] = true
+			}
+		}
+	}
+	lists := []anilist.CustomListMembership{}
+	for _, name := range names {
+		lists = append(lists, anilist.CustomListMembership{Name: name, Enabled: onList[name]})
+	}
+	return lists
+}
+
+// renderTabBar lists every tab with its entry count, highlighting the current one
+func (m *model) renderTabBar() string {
+	var items []string
+	for i, tab := range m.listTabs() {
+		label := tab.title()
+		if tab.listType.Personal() && m.collection != nil {
+			label = fmt.Sprintf("%s (%d)", label, len(m.tabEntries(tab)))
+		}
+		if i == m.listTabIndex {
+			label = activeTabStyle.Render(label)
+		}
+		items = append(items, label)
+	}
+
+	// Wrap the tab bar to the viewport width
+	var sb strings.Builder
+	lineLen := 0
+	for i, item := range items {
+		width := lipgloss.Width(item)
+		if i > 0 {
+			if lineLen+width+3 > m.viewport.Width {
+				sb.WriteString("\n")
+				lineLen = 0
+			} else {
+				sb.WriteString(" │ ")
+				lineLen += 3
+			}
+		}
+		sb.WriteString(item)
+		lineLen += width
+	}
+	return sb.String()
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+// trackerLocal keeps the list on disk while nobody is logged in
+const trackerLocal = "Local"
+
+// localList is the list kept without an account. Entries are keyed by
+// AniList media ID, so they can be pushed to an account later.
+type localList struct {
+	mu      sync.Mutex
+	path    string
+	err     error // why the file couldn't be read, saving would overwrite it
+	entries map[int]UserAnimeEntry
+}
+
+// localListFile is the format of the file at localListPath
+type localListFile struct {
+	Entries []UserAnimeEntry `json:"entries"`
+}
+
+// loadLocalList reads the local list. A missing file is an empty list. A file
+// that can't be read gives an empty list that refuses to save, along with the
+// error.
+func loadLocalList(path string) (*localList, error) {
+	l := &localList{path: path, entries: map[int]UserAnimeEntry{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	var file localListFile
+	if err == nil {
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		l.err = fmt.Errorf("reading local list %s: %w", path, err)
+		return l, l.err
+	}
+	for _, e := range file.Entries {
+		l.entries[e.Media.ID] = e
+	}
+	return l, nil
+}
+
+// save writes the list through a temporary file, so a crash never leaves
+// half of it behind
+func (l *localList) save() error {
+	if l.err != nil {
+		return l.err
+	}
+	file := localListFile{Entries: l.sorted()}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// sorted returns every entry, the most recently updated first
+func (l *localList) sorted() []UserAnimeEntry {
+	entries := make([]UserAnimeEntry, 0, len(l.entries))
+	for _, e := range l.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].UpdatedAt != entries[j].UpdatedAt {
+			return entries[i].UpdatedAt > entries[j].UpdatedAt
+		}
+		return entries[i].Media.ID < entries[j].Media.ID
+	})
+	return entries
+}
+
+// overlay fills in the list fields of entries that are on the local list.
+// AniList only sends them for logged in users.
+func (l *localList) overlay(entries []UserAnimeEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range entries {
+		if e, ok := l.entries[entries[i].Media.ID]; ok {
+			updateEntryFields(&entries[i], e)
+		}
+	}
+}
+
+// overlayLocal fills in the local list fields of entries while nobody is
+// logged in
+func (m *model) overlayLocal(entries ...UserAnimeEntry) []UserAnimeEntry {
+	if m.accessToken == "" {
+		m.localList.overlay(entries)
+	}
+	return entries
+}
+
+// localTracker keeps lists in a localList and reads anime data from AniList
+type localTracker struct {
+	list    *localList
+	aniList *anilist.Client
+}
+
+func (t *localTracker) Name() string {
+	return trackerLocal
+}
+
+func (t *localTracker) AuthCodeURL(_, _, _ string) string {
+	return ""
+}
+
+func (t *localTracker) ExchangeCode(context.Context, string, string, string) (*authToken, error) {
+	return nil, fmt.Errorf("the local list has no login")
+}
+
+func (t *localTracker) RefreshToken(context.Context, string) (*authToken, error) {
+	return nil, fmt.Errorf("the local list has no login")
+}
+
+func (t *localTracker) WithToken(string) Tracker {
+	return t
+}
+
+func (t *localTracker) Viewer(context.Context) (*anilist.Viewer, error) {
+	return nil, fmt.Errorf("the local list has no account")
+}
+
+func (t *localTracker) ListCollection(_ context.Context, _ int) ([]anilist.MediaListGroup, error) {
+	t.list.mu.Lock()
+	defer t.list.mu.Unlock()
+	if t.list.err != nil {
+		return nil, t.list.err
+	}
+
+	byStatus := map[string][]UserAnimeEntry{}
+	for _, e := range t.list.sorted() {
+		byStatus[e.Status] = append(byStatus[e.Status], e)
+	}
+	var groups []anilist.MediaListGroup
+	for _, tab := range statusTabs {
+		if list := byStatus[tab.status]; len(list) > 0 {
+			groups = append(groups, anilist.MediaListGroup{Name: tab.title(), Status: tab.status, Entries: list})
+		}
+	}
+	return groups, nil
+}
+
+func (t *localTracker) Search(ctx context.Context, filter anilist.MediaFilter, page, perPage int) (*anilist.MediaPage, error) {
+	result, err := t.aniList.SearchMedia(ctx, filter, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+	t.list.mu.Lock()
+	defer t.list.mu.Unlock()
+	for i, a := range result.Media {
+		if e, ok := t.list.entries[a.ID]; ok {
+			result.Media[i].MediaListEntry = &MediaListEntry{ID: e.ID, Status: e.Status, Progress: e.Progress, Score: e.Score}
+		}
+	}
+	return result, nil
+}
+
+// SaveEntry stores the entry with the anime as it is now, keeping the fields
+// the UI doesn't send. Start and completion dates are set the first time the
+// entry is watched or completed, like AniList's site does.
+func (t *localTracker) SaveEntry(_ context.Context, entry UserAnimeEntry) (*UserAnimeEntry, error) {
+	if entry.Media.ID <= 0 {
+		return nil, fmt.Errorf("%s isn't on AniList", entry.Media.Title.Preferred(""))
+	}
+	t.list.mu.Lock()
+	defer t.list.mu.Unlock()
+
+	prev, existed := t.list.entries[entry.Media.ID]
+	saved := prev
+	saved.ID = entry.Media.ID
+	saved.Status = entry.Status
+	saved.Progress = entry.Progress
+	saved.Score = entry.Score
+	saved.Media = entry.Media
+	saved.Media.MediaListEntry = nil
+
+	now := time.Now()
+	saved.UpdatedAt = now.Unix()
+	today := fuzzyDateOf(now)
+	if saved.StartedAt.Year == nil && (saved.Status == "CURRENT" || saved.Status == "COMPLETED") {
+		saved.StartedAt = today
+	}
+	if saved.CompletedAt.Year == nil && saved.Status == "COMPLETED" {
+		saved.CompletedAt = today
+	}
+
+	t.list.entries[entry.Media.ID] = saved
+	if err := t.list.save(); err != nil {
+		if existed {
+			t.list.entries[entry.Media.ID] = prev
+		} else {
+			delete(t.list.entries, entry.Media.ID)
+		}
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (t *localTracker) DeleteEntry(_ context.Context, entry UserAnimeEntry) error {
+	t.list.mu.Lock()
+	defer t.list.mu.Unlock()
+
+	prev, ok := t.list.entries[entry.Media.ID]
+	if !ok {
+		return fmt.Errorf("%s isn't on your list", entry.Media.Title.Preferred(""))
+	}
+	delete(t.list.entries, entry.Media.ID)
+	if err := t.list.save(); err != nil {
+		t.list.entries[entry.Media.ID] = prev
+		return err
+	}
+	return nil
+}
+
+func fuzzyDateOf(t time.Time) FuzzyDate {
+	year, month, day := t.Date()
+	m := int(month)
+	return FuzzyDate{Year: &year, Month: &m, Day: &day}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalTrackerSaveAndDelete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.json")
+	list, err := loadLocalList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := &localTracker{list: list}
+	anime := Anime{ID: 5114}
+
+	if _, err := tracker.SaveEntry(context.Background(), UserAnimeEntry{Media: anime, Status: "CURRENT", Progress: 3}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("list file mode %o, want 600", perm)
+	}
+	if e, ok := reloadLocalList(t, path).entries[anime.ID]; !ok || e.Progress != 3 || e.StartedAt.Year == nil {
+		t.Errorf("saved entry %+v", e)
+	}
+
+	if err := tracker.DeleteEntry(context.Background(), UserAnimeEntry{Media: anime}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloadLocalList(t, path).entries[anime.ID]; ok {
+		t.Error("deleted entry still in the file")
+	}
+	if err := tracker.DeleteEntry(context.Background(), UserAnimeEntry{Media: anime}); err == nil {
+		t.Error("deleting an entry that isn't on the list succeeded")
+	}
+}
+
+func TestLocalListKeepsUnreadableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.json")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := loadLocalList(path)
+	if err == nil {
+		t.Error("reading an unreadable list succeeded")
+	}
+	tracker := &localTracker{list: list}
+	if _, err := tracker.SaveEntry(context.Background(), UserAnimeEntry{Media: Anime{ID: 1}, Status: "PLANNING"}); err == nil {
+		t.Error("saving over an unreadable list succeeded")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "not json" {
+		t.Errorf("list file overwritten with %q", data)
+	}
+}
+
+func reloadLocalList(t *testing.T, path string) *localList {
+	t.Helper()
+	list, err := loadLocalList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
@@ -0,0 +1,103 @@
+package mal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Fields requested for every anime
+const animeFields = "alternative_titles,media_type,status,num_episodes,mean,start_season,main_picture,synopsis,my_list_status"
+
+// Fields of the user's list entries on top of the default ones
+const listStatusFields = "list_status{num_times_rewatched,start_date,finish_date,comments}"
+
+// Upper bound on pages fetched by methods that collect every page
+const maxPages = 20
+
+// Me returns the user the client's token belongs to
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.get(ctx, "/users/@me", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// AnimeList returns every entry of the user's anime list, the most recently
+// updated first
+func (c *Client) AnimeList(ctx context.Context) ([]ListEntry, error) {
+	params := url.Values{
+		"fields": {listStatusFields + "," + animeFields},
+		"sort":   {"list_updated_at"},
+		"limit":  {"1000"},
+		"nsfw":   {"true"},
+	}
+
+	var entries []ListEntry
+	next := "/users/@me/animelist"
+	for page := 0; page < maxPages && next != ""; page++ {
+		var data struct {
+			Data   []ListEntry `json:"data"`
+			Paging paging      `json:"paging"`
+		}
+		if err := c.get(ctx, next, params, &data); err != nil {
+			return nil, err
+		}
+		entries = append(entries, data.Data...)
+
+		// The next link carries every parameter already
+		next, params = data.Paging.Next, nil
+	}
+	return entries, nil
+}
+
+// SearchAnime returns anime matching a title and whether there are more
+// after offset+limit
+func (c *Client) SearchAnime(ctx context.Context, query string, limit, offset int) ([]Anime, bool, error) {
+	params := url.Values{
+		"q":      {query},
+		"limit":  {strconv.Itoa(limit)},
+		"offset": {strconv.Itoa(offset)},
+		"fields": {animeFields},
+	}
+
+	var data struct {
+		Data []struct {
+			Anime Anime `json:"node"`
+		} `json:"data"`
+		Paging paging `json:"paging"`
+	}
+	if err := c.get(ctx, "/anime", params, &data); err != nil {
+		return nil, false, err
+	}
+
+	anime := make([]Anime, len(data.Data))
+	for i, d := range data.Data {
+		anime[i] = d.Anime
+	}
+	return anime, data.Paging.Next != "", nil
+}
+
+// UpdateListStatus creates or updates the user's list entry for an anime
+func (c *Client) UpdateListStatus(ctx context.Context, animeID int, update ListStatusUpdate) (*ListStatus, error) {
+	form := url.Values{
+		"status":               {update.Status},
+		"score":                {strconv.Itoa(update.Score)},
+		"num_watched_episodes": {strconv.Itoa(update.NumWatchedEpisodes)},
+		"is_rewatching":        {strconv.FormatBool(update.IsRewatching)},
+	}
+
+	var status ListStatus
+	if err := c.send(ctx, http.MethodPatch, fmt.Sprintf("/anime/%d/my_list_status", animeID), form, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// DeleteListStatus removes an anime from the user's list
+func (c *Client) DeleteListStatus(ctx context.Context, animeID int) error {
+	return c.send(ctx, http.MethodDelete, fmt.Sprintf("/anime/%d/my_list_status", animeID), nil, nil)
+}
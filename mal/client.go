@@ -0,0 +1,146 @@
+package mal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// Endpoint is the MyAnimeList v2 API
+	Endpoint = "https://api.myanimelist.net/v2"
+
+	// AuthEndpoint serves MyAnimeList's OAuth authorize and token URLs
+	AuthEndpoint = "https://myanimelist.net/v1/oauth2"
+)
+
+// Client talks to the MyAnimeList v2 API. Endpoint and AuthEndpoint may
+// point at a local stand-in server.
+type Client struct {
+	HTTPClient   *http.Client
+	Endpoint     string
+	AuthEndpoint string
+	ClientID     string
+	ClientSecret string // only set for clients registered as web apps
+
+	token string
+}
+
+// NewClient creates an unauthenticated client for a registered API client
+func NewClient(clientID string) *Client {
+	return &Client{
+		HTTPClient:   http.DefaultClient,
+		Endpoint:     Endpoint,
+		AuthEndpoint: AuthEndpoint,
+		ClientID:     clientID,
+	}
+}
+
+// WithToken returns a copy of the client that sends token as a bearer token.
+// An empty token gives an unauthenticated client.
+func (c *Client) WithToken(token string) *Client {
+	clone := *c
+	clone.token = token
+	return &clone
+}
+
+// Authenticated reports whether the client sends an access token
+func (c *Client) Authenticated() bool {
+	return c.token != ""
+}
+
+// get fetches path, or a full URL like the paging links MyAnimeList returns,
+// and decodes the response into out
+func (c *Client) get(ctx context.Context, path string, params url.Values, out any) error {
+	target := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		target = c.Endpoint + path
+	}
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, out)
+}
+
+// send posts a form with method to path
+func (c *Client) send(ctx context.Context, method, path string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.Endpoint+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else {
+		req.Header.Set("X-MAL-CLIENT-ID", c.ClientID)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding MyAnimeList response: %w", err)
+	}
+	return nil
+}
+
+// responseError reads the error MyAnimeList sends with a failing status
+func responseError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	var envelope struct {
+		Error            string `json:"error"`
+		Message          string `json:"message"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if json.Unmarshal(body, &envelope) == nil {
+		apiErr.Code = envelope.Error
+		apiErr.Message = envelope.Message
+		if apiErr.Message == "" {
+			apiErr.Message = envelope.ErrorDescription
+		}
+	}
+	if apiErr.Message == "" && apiErr.Code == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// Error is returned when MyAnimeList answers with a failing HTTP status
+type Error struct {
+	StatusCode int
+	Code       string // like not_found or invalid_token
+	Message    string
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Code
+	} else if e.Code != "" {
+		msg = e.Code + ": " + msg
+	}
+	return fmt.Sprintf("MyAnimeList error (%d): %s", e.StatusCode, msg)
+}
@@ -0,0 +1,255 @@
+package mal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client talking to a stand-in server running handler
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := NewClient("test-client")
+	c.HTTPClient = server.Client()
+	c.Endpoint = server.URL + "/v2"
+	c.AuthEndpoint = server.URL + "/oauth2"
+	return c
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExchangeCode(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth2/token" || r.Method != http.MethodPost {
+			t.Errorf("got %s %s", r.Method, r.URL.Path)
+		}
+		r.ParseForm()
+		want := map[string]string{
+			"grant_type":    "authorization_code",
+			"client_id":     "test-client",
+			"code":          "the-code",
+			"redirect_uri":  "http://localhost/callback",
+			"code_verifier": "the-verifier",
+		}
+		for k, v := range want {
+			if got := r.PostForm.Get(k); got != v {
+				t.Errorf("%s = %q, want %q", k, got, v)
+			}
+		}
+		writeJSON(t, w, map[string]any{"access_token": "access", "refresh_token": "refresh", "expires_in": 2678400})
+	}))
+
+	token, err := c.ExchangeCode(context.Background(), "the-code", "http://localhost/callback", "the-verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" || token.ExpiresIn != 2678400 {
+		t.Errorf("got %+v", token)
+	}
+}
+
+func TestExchangeCodeError(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(t, w, map[string]any{"error": "invalid_grant", "message": "code expired"})
+	}))
+
+	_, err := c.ExchangeCode(context.Background(), "old", "http://localhost/callback", "v")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "invalid_grant" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != "old-refresh" {
+			t.Errorf("got form %v", r.PostForm)
+		}
+		writeJSON(t, w, map[string]any{"access_token": "new", "refresh_token": "new-refresh", "expires_in": 60})
+	}))
+
+	token, err := c.Refresh(context.Background(), "old-refresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "new" || token.RefreshToken != "new-refresh" {
+		t.Errorf("got %+v", token)
+	}
+}
+
+func TestMe(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if r.URL.Path != "/v2/users/@me" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		writeJSON(t, w, map[string]any{"id": 42, "name": "someone"})
+	}))
+
+	user, err := c.WithToken("secret").Me(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 42 || user.Name != "someone" {
+		t.Errorf("got %+v", user)
+	}
+}
+
+func TestUnauthenticatedSendsClientID(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-MAL-CLIENT-ID"); got != "test-client" {
+			t.Errorf("X-MAL-CLIENT-ID = %q", got)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("unauthenticated request sent a token")
+		}
+		writeJSON(t, w, map[string]any{"data": []any{}, "paging": map[string]any{}})
+	}))
+
+	if _, _, err := c.SearchAnime(context.Background(), "frieren", 10, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMeUnauthorized(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(t, w, map[string]any{"error": "invalid_token"})
+	}))
+
+	_, err := c.WithToken("expired").Me(context.Background())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestAnimeListFollowsPaging(t *testing.T) {
+	var serverURL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/users/@me/animelist", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("offset")
+		switch page {
+		case "":
+			if r.URL.Query().Get("fields") == "" {
+				t.Error("first page without fields")
+			}
+			writeJSON(t, w, map[string]any{
+				"data": []any{
+					map[string]any{"node": map[string]any{"id": 1, "title": "One"}, "list_status": map[string]any{"status": "watching", "num_episodes_watched": 3}},
+				},
+				"paging": map[string]any{"next": serverURL + "/v2/users/@me/animelist?offset=1"},
+			})
+		case "1":
+			writeJSON(t, w, map[string]any{
+				"data": []any{
+					map[string]any{"node": map[string]any{"id": 2, "title": "Two"}, "list_status": map[string]any{"status": "completed", "score": 8}},
+				},
+				"paging": map[string]any{},
+			})
+		default:
+			t.Errorf("unexpected page %q", page)
+		}
+	})
+	c := newTestClient(t, mux)
+	serverURL = c.Endpoint[:len(c.Endpoint)-len("/v2")]
+
+	entries, err := c.WithToken("secret").AnimeList(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	if entries[0].Anime.ID != 1 || entries[0].ListStatus.NumEpisodesWatched != 3 {
+		t.Errorf("first entry %+v", entries[0])
+	}
+	if entries[1].Anime.Title != "Two" || entries[1].ListStatus.Score != 8 || entries[1].ListStatus.Status != StatusCompleted {
+		t.Errorf("second entry %+v", entries[1])
+	}
+}
+
+func TestAnimeListStopsAtMaxPages(t *testing.T) {
+	requests := 0
+	var serverURL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/users/@me/animelist", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeJSON(t, w, map[string]any{
+			"data":   []any{},
+			"paging": map[string]any{"next": fmt.Sprintf("%s/v2/users/@me/animelist?offset=%d", serverURL, requests)},
+		})
+	})
+	c := newTestClient(t, mux)
+	serverURL = c.Endpoint[:len(c.Endpoint)-len("/v2")]
+
+	if _, err := c.WithToken("secret").AnimeList(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if requests != maxPages {
+		t.Errorf("made %d requests, want %d", requests, maxPages)
+	}
+}
+
+func TestUpdateListStatus(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/v2/anime/21/my_list_status" {
+			t.Errorf("got %s %s", r.Method, r.URL.Path)
+		}
+		r.ParseForm()
+		want := map[string]string{
+			"status":               StatusCompleted,
+			"score":                "9",
+			"num_watched_episodes": "12",
+			"is_rewatching":        "true",
+		}
+		for k, v := range want {
+			if got := r.PostForm.Get(k); got != v {
+				t.Errorf("%s = %q, want %q", k, got, v)
+			}
+		}
+		writeJSON(t, w, map[string]any{"status": "completed", "score": 9, "num_episodes_watched": 12, "is_rewatching": true})
+	}))
+
+	status, err := c.WithToken("secret").UpdateListStatus(context.Background(), 21, ListStatusUpdate{
+		Status:             StatusCompleted,
+		Score:              9,
+		NumWatchedEpisodes: 12,
+		IsRewatching:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Score != 9 || !status.IsRewatching {
+		t.Errorf("got %+v", status)
+	}
+}
+
+func TestDeleteListStatus(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/v2/anime/21/my_list_status" {
+			t.Errorf("got %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	if err := c.WithToken("secret").DeleteListStatus(context.Background(), 21); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,85 @@
+package mal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Token is the result of a successful login. MyAnimeList access tokens
+// expire after about a month.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // seconds
+}
+
+// AuthCodeURL is the page the user logs in on. MyAnimeList requires PKCE and
+// only supports the plain method, so verifier is sent as the challenge.
+func (c *Client) AuthCodeURL(redirectURI, state, verifier string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.ClientID},
+		"redirect_uri":          {redirectURI},
+		"state":                 {state},
+		"code_challenge":        {verifier},
+		"code_challenge_method": {"plain"},
+	}
+	return c.AuthEndpoint + "/authorize?" + params.Encode()
+}
+
+// ExchangeCode trades the code of the login redirect for a token
+func (c *Client) ExchangeCode(ctx context.Context, code, redirectURI, verifier string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {c.ClientID},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	return c.requestToken(ctx, form)
+}
+
+// Refresh trades a refresh token for a new token before the old one expires
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {c.ClientID},
+		"refresh_token": {refreshToken},
+	}
+	return c.requestToken(ctx, form)
+}
+
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*Token, error) {
+	if c.ClientSecret != "" {
+		form.Set("client_secret", c.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.AuthEndpoint+"/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, responseError(resp)
+	}
+	var token Token
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in response")
+	}
+	return &token, nil
+}
@@ -0,0 +1,77 @@
+package mal
+
+import "time"
+
+// List statuses as the API names them
+const (
+	StatusWatching    = "watching"
+	StatusCompleted   = "completed"
+	StatusOnHold      = "on_hold"
+	StatusDropped     = "dropped"
+	StatusPlanToWatch = "plan_to_watch"
+)
+
+// User is the account a token belongs to
+type User struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Anime is an anime as returned by list and search requests with
+// animeFields
+type Anime struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	MainPicture struct {
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	} `json:"main_picture"`
+	AlternativeTitles struct {
+		Synonyms []string `json:"synonyms"`
+		En       string   `json:"en"`
+		Ja       string   `json:"ja"`
+	} `json:"alternative_titles"`
+	MediaType   string      `json:"media_type"` // tv, movie, ova, ...
+	Status      string      `json:"status"`     // finished_airing, currently_airing, not_yet_aired
+	NumEpisodes int         `json:"num_episodes"`
+	Mean        float64     `json:"mean"`
+	StartSeason *Season     `json:"start_season"`
+	Synopsis    string      `json:"synopsis"`
+	ListStatus  *ListStatus `json:"my_list_status"`
+}
+
+type Season struct {
+	Year   int    `json:"year"`
+	Season string `json:"season"` // winter, spring, summer, fall
+}
+
+// ListStatus is the user's list entry for an anime
+type ListStatus struct {
+	Status             string    `json:"status"`
+	Score              int       `json:"score"` // 0 to 10, 0 means not scored
+	NumEpisodesWatched int       `json:"num_episodes_watched"`
+	IsRewatching       bool      `json:"is_rewatching"`
+	NumTimesRewatched  int       `json:"num_times_rewatched"`
+	StartDate          string    `json:"start_date"`  // YYYY-MM-DD, YYYY-MM or YYYY
+	FinishDate         string    `json:"finish_date"` // same as StartDate
+	Comments           string    `json:"comments"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// ListEntry is one anime of the user's list
+type ListEntry struct {
+	Anime      Anime      `json:"node"`
+	ListStatus ListStatus `json:"list_status"`
+}
+
+// ListStatusUpdate holds the list fields written by UpdateListStatus
+type ListStatusUpdate struct {
+	Status             string
+	Score              int
+	NumWatchedEpisodes int
+	IsRewatching       bool
+}
+
+type paging struct {
+	Next string `json:"next"`
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// malList is MyAnimeList's XML list export, the format most list tools read
+// and write
+type malList struct {
+	XMLName xml.Name   `xml:"myanimelist"`
+	Info    malInfo    `xml:"myinfo"`
+	Anime   []malAnime `xml:"anime"`
+}
+
+type malInfo struct {
+	UserName         string `xml:"user_name"`
+	ExportType       int    `xml:"user_export_type"` // 1 is an anime list
+	TotalAnime       int    `xml:"user_total_anime"`
+	TotalWatching    int    `xml:"user_total_watching"`
+	TotalCompleted   int    `xml:"user_total_completed"`
+	TotalOnHold      int    `xml:"user_total_onhold"`
+	TotalDropped     int    `xml:"user_total_dropped"`
+	TotalPlanToWatch int    `xml:"user_total_plantowatch"`
+}
+
+type malAnime struct {
+	ID              int    `xml:"series_animedb_id"`
+	Title           string `xml:"series_title"`
+	Type            string `xml:"series_type"`
+	Episodes        int    `xml:"series_episodes"`
+	WatchedEpisodes int    `xml:"my_watched_episodes"`
+	StartDate       string `xml:"my_start_date"`
+	FinishDate      string `xml:"my_finish_date"`
+	Score           int    `xml:"my_score"`
+	Status          string `xml:"my_status"`
+	Comments        string `xml:"my_comments"`
+	TimesWatched    int    `xml:"my_times_watched"`
+	Rewatching      int    `xml:"my_rewatching"`
+	UpdateOnImport  int    `xml:"update_on_import"`
+}
+
+// MyAnimeList statuses, rewatching is a flag on completed entries there
+const (
+	malWatching    = "Watching"
+	malCompleted   = "Completed"
+	malOnHold      = "On-Hold"
+	malDropped     = "Dropped"
+	malPlanToWatch = "Plan to Watch"
+)
+
+func malStatus(status string) string {
+	switch status {
+	case "CURRENT":
+		return malWatching
+	case "COMPLETED", "REPEATING":
+		return malCompleted
+	case "PAUSED":
+		return malOnHold
+	case "DROPPED":
+		return malDropped
+	}
+	return malPlanToWatch
+}
+
+func malType(format string) string {
+	switch format {
+	case "TV", "TV_SHORT":
+		return "TV"
+	case "OVA", "ONA":
+		return format
+	case "":
+		return "Unknown"
+	}
+	return strings.ToUpper(format[:1]) + strings.ToLower(format[1:])
+}
+
+// aniListStatus maps a MyAnimeList status back, some exports use numbers
+func aniListStatus(a malAnime) string {
+	switch strings.TrimSpace(a.Status) {
+	case malWatching, "1":
+		return "CURRENT"
+	case malCompleted, "2":
+		if a.Rewatching == 1 {
+			return "REPEATING"
+		}
+		return "COMPLETED"
+	case malOnHold, "3":
+		return "PAUSED"
+	case malDropped, "4":
+		return "DROPPED"
+	}
+	return "PLANNING"
+}
+
+// toTen converts a score to MyAnimeList's whole 10 point scale
+func (f scoreFormat) toTen(score float64) int {
+	return int(math.Round(score * 10 / f.max()))
+}
+
+// fromTen converts a MyAnimeList score, 0 stays unscored
+func (f scoreFormat) fromTen(score int) float64 {
+	s := float64(score) * f.max() / 10
+	if f != scorePoint10Decimal && f != "" {
+		s = math.Round(s)
+	}
+	if score > 0 && s < 1 {
+		s = 1
+	}
+	return s
+}
+
+// malDate formats a date like MyAnimeList, with zeros for unknown parts
+func malDate(d FuzzyDate) string {
+	part := func(p *int) int {
+		if p == nil {
+			return 0
+		}
+		return *p
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", part(d.Year), part(d.Month), part(d.Day))
+}
+
+func parseMalDate(s string) FuzzyDate {
+	var d FuzzyDate
+	var year, month, day int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d-%d-%d", &year, &month, &day); err != nil || year == 0 {
+		return d
+	}
+	d.Year = &year
+	if month > 0 {
+		d.Month = &month
+		if day > 0 {
+			d.Day = &day
+		}
+	}
+	return d
+}
+
+// newMalList converts list entries to a MyAnimeList export. Anime without a
+// MyAnimeList ID can't be imported there and are left out.
+func newMalList(username string, entries []UserAnimeEntry, scores scoreFormat) (*malList, int) {
+	list := &malList{Info: malInfo{UserName: username, ExportType: 1}}
+	skipped := 0
+	for _, e := range entries {
+		if e.Media.IDMal == 0 {
+			skipped++
+			continue
+		}
+		a := malAnime{
+			ID:              e.Media.IDMal,
+			Title:           e.Media.Title.Preferred("ROMAJI"),
+			Type:            malType(e.Media.Format),
+			WatchedEpisodes: e.Progress,
+			StartDate:       malDate(e.StartedAt),
+			FinishDate:      malDate(e.CompletedAt),
+			Score:           scores.toTen(e.Score),
+			Status:          malStatus(e.Status),
+			Comments:        e.Notes,
+			TimesWatched:    e.Repeat,
+			UpdateOnImport:  1,
+		}
+		if e.Media.Episodes != nil {
+			a.Episodes = *e.Media.Episodes
+		}
+		if e.Status == "REPEATING" {
+			a.Rewatching = 1
+		}
+		list.Anime = append(list.Anime, a)
+
+		switch a.Status {
+		case malWatching:
+			list.Info.TotalWatching++
+		case malCompleted:
+			list.Info.TotalCompleted++
+		case malOnHold:
+			list.Info.TotalOnHold++
+		case malDropped:
+			list.Info.TotalDropped++
+		default:
+			list.Info.TotalPlanToWatch++
+		}
+	}
+	list.Info.TotalAnime = len(list.Anime)
+	return list, skipped
+}
+
+// writeListExport saves the entries as MyAnimeList XML and as AniList JSON
+// next to each other and returns the path of the XML file
+func writeListExport(dir, username string, entries []UserAnimeEntry, scores scoreFormat) (string, int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, err
+	}
+	base := filepath.Join(dir, fmt.Sprintf("animelist_%s_%s", username, time.Now().Format("2006-01-02")))
+
+	list, skipped := newMalList(username, entries, scores)
+	out, err := xml.MarshalIndent(list, "", "\t")
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.WriteFile(base+".xml", append([]byte(xml.Header), out...), 0600); err != nil {
+		return "", 0, err
+	}
+
+	out, err = json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.WriteFile(base+".json", out, 0600); err != nil {
+		return "", 0, err
+	}
+	return base + ".xml", skipped, nil
+}
+
+// readMalList reads a MyAnimeList export, gzipped as MyAnimeList serves it
+// or plain
+func readMalList(path string) (*malList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var list malList
+	if err := xml.NewDecoder(r).Decode(&list); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	if list.Info.ExportType != 0 && list.Info.ExportType != 1 {
+		return nil, fmt.Errorf("%s is a manga list", filepath.Base(path))
+	}
+	return &list, nil
+}
@@ -1,67 +1,36 @@
 package main
 
 import (
+	"context"
+
 	"github.com/anacrolix/torrent"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+	"github.com/sunnygitgud/sakuhaku/mal"
 	tc "github.com/sunnygitgud/sakuhaku/torrentclient"
 )
 
 // ----- Models -----
-type Anime struct {
-	ID          int    `json:"id"`
-	Title       Title  `json:"title"`
-	Format      string `json:"format"`
-	Status      string `json:"status"`
-	Episodes    *int   `json:"episodes"`
-	Score       *int   `json:"averageScore"`
-	Season      string `json:"season"`
-	SeasonYear  *int   `json:"seasonYear"`
-	Description string `json:"description"`
-	CoverImage  struct {
-		Large string `json:"large"`
-	} `json:"coverImage"`
-	SiteURL string `json:"siteUrl"`
-}
-
-type Title struct {
-	Romaji  string `json:"romaji"`
-	English string `json:"english"`
-}
 
-type UserAnimeEntry struct {
-	ID        int     `json:"id"`
-	Status    string  `json:"status"`
-	Progress  int     `json:"progress"`
-	Score     float64 `json:"score"`
-	Media     Anime   `json:"media"`
-	UpdatedAt int64   `json:"updatedAt"`
-}
-
-type AniListResponse struct {
-	Data struct {
-		Page struct {
-			PageInfo struct {
-				Total       int  `json:"total"`
-				PerPage     int  `json:"perPage"`
-				CurrentPage int  `json:"currentPage"`
-				LastPage    int  `json:"lastPage"`
-				HasNextPage bool `json:"hasNextPage"`
-			} `json:"pageInfo"`
-			Media []Anime `json:"media"`
-		} `json:"Page"`
-		MediaListCollection struct {
-			Lists []struct {
-				Name    string           `json:"name"`
-				Entries []UserAnimeEntry `json:"entries"`
-			} `json:"lists"`
-		} `json:"MediaListCollection"`
-		Viewer struct {
-			ID   int    `json:"id"`
-			Name string `json:"name"`
-		} `json:"Viewer"`
-	} `json:"data"`
-}
+// AniList types used throughout the UI
+type (
+	Anime             = anilist.Media
+	Title             = anilist.Title
+	MediaListEntry    = anilist.MediaListEntry
+	UserAnimeEntry    = anilist.MediaList
+	AnimeDetail       = anilist.MediaDetail
+	AiringSchedule    = anilist.AiringSchedule
+	NextAiringEpisode = anilist.NextAiringEpisode
+	FuzzyDate         = anilist.FuzzyDate
+	Recommendation    = anilist.Recommendation
+	FranchiseNode     = anilist.FranchiseNode
+	ListActivity      = anilist.ListActivity
+	UserMediaList     = anilist.UserMediaList
+	AnimeStatistics   = anilist.AnimeStatistics
+	Notification      = anilist.Notification
+)
 
 type Torrent struct {
 	ID         int    `json:"id"`
@@ -76,6 +45,16 @@ type Torrent struct {
 	Source     string `json:"source"`
 }
 
+// passphrasePrompt is the passphrase asked for on the login screen
+type passphrasePrompt int
+
+const (
+	promptNone passphrasePrompt = iota
+	promptUnlock
+	promptNew
+	promptConfirm
+)
+
 type ViewMode int
 
 const (
@@ -83,48 +62,145 @@ const (
 	ModeUserList
 	ModeAnimeSearch
 	ModeTorrents
+	ModeDetail
+	ModeSchedule
+	ModeRecommendations
+	ModeFranchise
+	ModeSocial
+	ModeStats
+	ModeNotifications
+	ModeImport
 )
 
 type model struct {
 	// Auth
-	accessToken string
-	username    string
-	userID      int
+	accessToken  string
+	username     string
+	userID       int
+	aniList      *anilist.Client
+	aniListCache *anilist.Cache
+	mal          *mal.Client
+	trackerName  string // tracker of the account, see tracker.go
+	localList    *localList
+
+	// Display settings of the account, see preferences.go
+	titleLanguage string
+	scoreFormat   scoreFormat
+	session       int // counts logins, background work of older ones is dropped
 
 	// Common
-	mode        ViewMode
-	ready       bool
-	viewport    viewport.Model
-	searchMode  bool
-	searchInput string
-	loginMsg    string
+	mode          ViewMode
+	ready         bool
+	viewport      viewport.Model
+	height        int  // of the terminal, the viewport gets what the header and footer leave
+	showKeys      bool // the footer lists every key of the view
+	searchMode    bool
+	searchInput   string
+	profiles      *profileStore
+	cancelLogin   context.CancelFunc
+	passPrompt    passphrasePrompt
+	passInput     textinput.Model
+	newPassphrase string
+	pinMode       bool
+	pinInput      textinput.Model
+	loginMsg      string
+	statusMsg     string
 
 	// List type tracking
-	currentListType ListType
+	currentListType   ListType
+	currentCustomList string
+	listTabIndex      int
+	collection        []anilist.MediaListGroup
 
 	// User list mode
 	userEntries     []UserAnimeEntry
 	userEntryCursor int
+	listPage        int
+	listHasNextPage bool
+	listLoadingMore bool
+
+	// List editing
+	scoreMode     bool
+	scoreInput    string
+	entryEditor   *entryEditor
+	pendingDelete *UserAnimeEntry // waiting for 'y' to delete it
+	entrySaves    map[int]int     // number of the latest save of each media ID
 
 	// Anime search mode
 	anime           []Anime
 	animeCursor     int
 	animePage       int
 	animeTotalPages int
-	animeQuery      string
+	searchFilter    anilist.MediaFilter
+	filterForm      *form
+	filterErr       string
+
+	// Detail mode
+	detail           *AnimeDetail
+	detailReturnMode ViewMode
+
+	// Schedule mode
+	schedules          []AiringSchedule
+	scheduleCursor     int
+	scheduleSeasonWide bool
+	scheduleReturnMode ViewMode
+
+	// Recommendations mode, one level per anime drilled into
+	recStack      []recommendationLevel
+	recReturnMode ViewMode
+
+	// Franchise mode
+	franchise           *franchiseView
+	franchiseReturnMode ViewMode
+
+	// Social mode, the following feed and followed users' entries by media ID
+	activities          []ListActivity
+	activityCursor      int
+	activityPage        int
+	activityHasNextPage bool
+	activityLoadingMore bool
+	followedLists       map[int][]UserMediaList
+	socialReturnMode    ViewMode
+
+	// Stats mode
+	stats           *AnimeStatistics
+	statsReturnMode ViewMode
+
+	// Notifications mode. notificationsUnread is how many of the loaded
+	// notifications were unread when the inbox was opened.
+	unreadNotifications     int
+	notifications           []Notification
+	notificationCursor      int
+	notificationPage        int
+	notificationHasNextPage bool
+	notificationLoadingMore bool
+	notificationsUnread     int
+	notificationReturnMode  ViewMode
+
+	// Import mode, the dry run and progress of a MyAnimeList import
+	importForm       *form
+	listImport       *listImport
+	importReturnMode ViewMode
 
 	// Torrent mode
-	torrents         []Torrent
-	torrentCursor    int
-	torrentPage      int
-	selectedTorrents map[int]struct{}
-	selectedAnime    *Anime
+	torrents          []Torrent
+	torrentCursor     int
+	torrentPage       int
+	selectedTorrents  map[int]struct{}
+	selectedAnime     *Anime
+	torrentReturnMode ViewMode
+	torrentSearch     int // counts searches, results of older ones are dropped
+	torrentShown      int // search whose results are in torrents
+	torrentPending    int // sources the search still waits for
+	torrentCancel     context.CancelFunc
+	torrentSeen       map[string]bool // torrentKey of every result
 
 	// Torrent client
 	torrentClient    *tc.TorrentClient
 	activeTorrent    *torrent.Torrent
 	streamURL        string
 	downloadProgress float64
+	playing          *playbackSession
 
 	//spinner
 	spinner    spinner.Model
@@ -140,6 +216,11 @@ const (
 	ListTrending
 	ListPopularSeason
 	ListTopRated
+	ListCompleted
+	ListPaused
+	ListDropped
+	ListRepeating
+	ListCustom
 )
 
 func (lt ListType) String() string {
@@ -154,7 +235,27 @@ func (lt ListType) String() string {
 		return "Popular This Season"
 	case ListTopRated:
 		return "Top Rated"
+	case ListCompleted:
+		return "Completed"
+	case ListPaused:
+		return "Paused"
+	case ListDropped:
+		return "Dropped"
+	case ListRepeating:
+		return "Rewatching"
+	case ListCustom:
+		return "Custom List"
 	default:
 		return "Unknown"
 	}
 }
+
+// Personal reports whether the list type shows entries of the user's own lists
+func (lt ListType) Personal() bool {
+	switch lt {
+	case ListTrending, ListPopularSeason, ListTopRated:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mpv JSON IPC: https://mpv.io/manual/stable/#json-ipc
+
+// playbackSession remembers what is being played so that the AniList entry
+// can be updated once the episode has been watched
+type playbackSession struct {
+	anime Anime
+	file  string
+}
+
+// episodeWatchedMsg is sent when playback passes the watch threshold
+type episodeWatchedMsg struct {
+	session playbackSession
+}
+
+// playbackEndedMsg is sent when the player exits before the threshold
+type playbackEndedMsg struct{}
+
+type mpvEvent struct {
+	Event string  `json:"event"`
+	Name  string  `json:"name"`
+	Data  float64 `json:"data"`
+}
+
+var mpvSocketCounter atomic.Int64
+
+// newMpvIPCPath returns a unique IPC path for a new mpv instance
+func newMpvIPCPath() string {
+	return mpvIPCPath(fmt.Sprintf("sakuhaku-mpv-%d-%d", os.Getpid(), mpvSocketCounter.Add(1)))
+}
+
+// watchMpvPlayback follows percent-pos over mpv's IPC socket and reports the
+// session as watched once it reaches threshold percent
+func watchMpvPlayback(ipcPath string, session playbackSession, threshold float64) tea.Cmd {
+	return func() tea.Msg {
+		// mpv creates the socket shortly after starting
+		var conn io.ReadWriteCloser
+		var err error
+		for i := 0; i < 50; i++ {
+			conn, err = dialMpv(ipcPath)
+			if err == nil {
+				break
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+		if err != nil {
+			debugLog(fmt.Sprintf("mpv ipc: %v", err))
+			return playbackEndedMsg{}
+		}
+		defer conn.Close()
+		defer removeMpvIPCPath(ipcPath)
+
+		if _, err := conn.Write([]byte(`{"command":["observe_property",1,"percent-pos"]}` + "\n")); err != nil {
+			debugLog(fmt.Sprintf("mpv ipc: %v", err))
+			return playbackEndedMsg{}
+		}
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			var event mpvEvent
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				continue
+			}
+			if event.Event == "property-change" && event.Name == "percent-pos" && event.Data >= threshold {
+				return episodeWatchedMsg{session: session}
+			}
+		}
+
+		return playbackEndedMsg{}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+func mpvIPCPath(name string) string {
+	return filepath.Join(os.TempDir(), name+".sock")
+}
+
+func dialMpv(path string) (io.ReadWriteCloser, error) {
+	return net.Dial("unix", path)
+}
+
+func removeMpvIPCPath(path string) {
+	os.Remove(path)
+}
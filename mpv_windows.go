@@ -0,0 +1,24 @@
+//go:build windows
+
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// mpv listens on a named pipe on Windows
+func mpvIPCPath(name string) string {
+	return `\\.\pipe\` + name
+}
+
+func dialMpv(path string) (io.ReadWriteCloser, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Named pipes disappear with the process that created them
+func removeMpvIPCPath(path string) {}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+// How often the unread notification count is checked
+const notificationPollInterval = 5 * time.Minute
+
+// Notifications fetched per page of the inbox
+const notificationsPerPage = 30
+
+var unreadBadgeStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+
+// unreadCountMsg carries the unread count for one login session. Counts of
+// an earlier session are dropped, which also stops its polling.
+type unreadCountMsg struct {
+	session int
+	count   int
+	err     error
+}
+
+// notificationPollMsg asks for the unread count again
+type notificationPollMsg struct {
+	session int
+}
+
+type notificationsMsg struct {
+	notifications []Notification
+	page          int
+	hasNextPage   bool
+	err           error
+}
+
+func fetchUnreadCount(client *anilist.Client, session int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		count, err := client.UnreadNotificationCount(ctx)
+		return unreadCountMsg{session: session, count: count, err: err}
+	}
+}
+
+func pollNotifications(session int) tea.Cmd {
+	return tea.Tick(notificationPollInterval, func(time.Time) tea.Msg {
+		return notificationPollMsg{session: session}
+	})
+}
+
+func fetchNotifications(client *anilist.Client, page int, markRead bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		result, err := client.Notifications(ctx, page, notificationsPerPage, markRead)
+		if err != nil {
+			return notificationsMsg{page: page, err: err}
+		}
+		return notificationsMsg{
+			notifications: result.Notifications,
+			page:          page,
+			hasNextPage:   result.PageInfo.HasNextPage,
+		}
+	}
+}
+
+// watchNotifications starts checking the unread count of the session
+func (m *model) watchNotifications() tea.Cmd {
+	if !m.aniListAccount() {
+		return nil
+	}
+	return fetchUnreadCount(m.aniList, m.session)
+}
+
+func (m *model) setUnreadCount(msg unreadCountMsg) tea.Cmd {
+	if msg.session != m.session || !m.aniListAccount() {
+		return nil
+	}
+	if msg.err != nil {
+		debugLog(fmt.Sprintf("Checking notifications failed: %v", msg.err))
+	} else {
+		m.unreadNotifications = msg.count
+	}
+	return pollNotifications(msg.session)
+}
+
+// openNotifications shows the inbox, marking everything in it read
+func (m *model) openNotifications() tea.Cmd {
+	if !m.requireAniList("see your notifications") {
+		return nil
+	}
+	if m.mode != ModeNotifications {
+		m.notificationReturnMode = m.mode
+	}
+	m.loading = true
+	m.loadingMsg = "Loading notifications..."
+	return tea.Batch(m.spinner.Tick, fetchNotifications(m.aniList, 1, true))
+}
+
+func (m *model) setNotifications(msg notificationsMsg) {
+	m.loading = false
+	m.notificationLoadingMore = false
+	if msg.err != nil {
+		m.statusMsg = fmt.Sprintf("Failed to load notifications: %v", msg.err)
+		return
+	}
+
+	if msg.page <= 1 {
+		// The newest ones were unread until now
+		m.notificationsUnread = m.unreadNotifications
+		m.unreadNotifications = 0
+		m.notifications = msg.notifications
+		m.notificationCursor = 0
+	} else {
+		m.notifications = append(m.notifications, msg.notifications...)
+	}
+	m.notificationPage = msg.page
+	m.notificationHasNextPage = msg.hasNextPage
+	m.mode = ModeNotifications
+}
+
+func (m *model) selectedNotification() (Notification, bool) {
+	if m.notificationCursor < len(m.notifications) {
+		return m.notifications[m.notificationCursor], true
+	}
+	return Notification{}, false
+}
+
+// loadMoreNotifications requests the next page once the cursor reaches the end
+func (m *model) loadMoreNotifications() tea.Cmd {
+	if !m.notificationHasNextPage || m.notificationLoadingMore || m.notificationCursor < len(m.notifications)-1 {
+		return nil
+	}
+	m.notificationLoadingMore = true
+	return fetchNotifications(m.aniList, m.notificationPage+1, false)
+}
+
+func (m *model) handleNotificationKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		if m.notificationCursor > 0 {
+			m.notificationCursor--
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+		}
+	case "down", "j":
+		if m.notificationCursor < len(m.notifications)-1 {
+			m.notificationCursor++
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+			return m.loadMoreNotifications()
+		}
+	case "enter":
+		// Torrents for the aired episode, or the anime of the notification
+		n, ok := m.selectedNotification()
+		if !ok || n.Media == nil {
+			return nil
+		}
+		queries := searchTitles(*n.Media)
+		if n.Type == "AIRING" {
+			queries = episodeQueries(queries, n.Episode)
+		}
+		m.selectedAnime = n.Media
+		m.loading = true
+		m.loadingMsg = "looking for torrents..."
+		return tea.Batch(m.spinner.Tick, m.searchTorrents(queries...))
+	}
+	return nil
+}
+
+// notificationText describes a notification the way AniList's inbox does
+func (m *model) notificationText(n Notification) string {
+	switch {
+	case n.Type == "AIRING" && n.Media != nil && len(n.Contexts) == 3:
+		return fmt.Sprintf("%s%d%s%s%s", n.Contexts[0], n.Episode, n.Contexts[1], m.animeTitle(*n.Media), n.Contexts[2])
+	case n.Media != nil:
+		return m.animeTitle(*n.Media) + n.Context
+	case n.User != nil:
+		return n.User.Name + n.Context
+	}
+	return strings.TrimSpace(n.Context)
+}
+
+func notificationIcon(n Notification) string {
+	switch {
+	case n.Type == "AIRING":
+		return "📺"
+	case n.Type == "RELATED_MEDIA_ADDITION":
+		return "➕"
+	case n.Type == "FOLLOWING":
+		return "👤"
+	case strings.HasSuffix(n.Type, "LIKE"):
+		return "♥"
+	}
+	return "💬"
+}
+
+// unreadBadge is the unread count shown next to the header title
+func (m *model) unreadBadge() string {
+	if !m.aniListAccount() || m.unreadNotifications == 0 {
+		return ""
+	}
+	return unreadBadgeStyle.Render(fmt.Sprintf(" 🔔 %d ", m.unreadNotifications))
+}
+
+func (m *model) renderNotificationsContent() string {
+	if len(m.notifications) == 0 {
+		return "No notifications."
+	}
+
+	var sb strings.Builder
+	for i, n := range m.notifications {
+		cursor := "  "
+		if i == m.notificationCursor {
+			cursor = "▶ "
+		}
+		unread := " "
+		if i < m.notificationsUnread {
+			unread = unreadBadgeStyle.Render("●")
+		}
+		text := truncateToWidth(m.notificationText(n), max(20, m.viewport.Width-20))
+		sb.WriteString(fmt.Sprintf("%s%s %s %-8s %s\n", cursor, unread, notificationIcon(n), formatTime(int(n.CreatedAt)), text))
+	}
+	if m.notificationLoadingMore {
+		sb.WriteString("\n  Loading more...\n")
+	}
+	return sb.String()
+}
@@ -1,15 +1,12 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"encoding/xml"
 	"fmt"
-	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
-
-	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Nyaa RSS Feed structures
@@ -100,112 +97,31 @@ func parseIntString(s string) int {
 	return val
 }
 
-// Perform Nyaa.si search
-func performNyaaSearch(query string) tea.Cmd {
-	return func() tea.Msg {
-		// Nyaa.si RSS feed endpoint
-		apiURL := fmt.Sprintf("https://nyaa.si/?page=rss&q=%s&c=1_2&f=0", url.QueryEscape(query))
-
-		resp, err := http.Get(apiURL)
-		if err != nil {
-			return torrentSearchResultMsg(nil)
-		}
-		defer resp.Body.Close()
-
-		var rss NyaaRSS
-		if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
-			return torrentSearchResultMsg(nil)
-		}
-
-		// Convert to our Torrent struct
-		torrents := make([]Torrent, 0, len(rss.Channel.Items))
-		for i, item := range rss.Channel.Items {
-			torrents = append(torrents, item.toTorrent(i))
-		}
-
-		return torrentSearchResultMsg(torrents)
-	}
-}
+// nyaaSource searches Nyaa's RSS feed, English-translated anime only
+type nyaaSource struct{}
 
-// Combined search from multiple sources
-type combinedTorrentMsg struct {
-	torrents []Torrent
-	source   string
+func (nyaaSource) Name() string {
+	return "nyaa"
 }
 
-func performCombinedSearch(query string) tea.Cmd {
-	return func() tea.Msg {
-		// Search both AnimeTosho and Nyaa concurrently
-		animetoshoChan := make(chan []Torrent, 1)
-		nyaaChan := make(chan []Torrent, 1)
-
-		// AnimeTosho search
-		go func() {
-			apiURL := fmt.Sprintf("https://feed.animetosho.org/json?qx=1&q=%s", url.QueryEscape(query))
-			resp, err := http.Get(apiURL)
-			if err != nil {
-				animetoshoChan <- nil
-				return
-			}
-			defer resp.Body.Close()
-
-			var torrents []Torrent
-			if err := json.NewDecoder(resp.Body).Decode(&torrents); err != nil {
-				animetoshoChan <- nil
-				return
-			}
-
-			// Tag source
-			for i := range torrents {
-				torrents[i].ID = i
-				torrents[i].Source = "animetosho"
-			}
-			animetoshoChan <- torrents
-		}()
-
-		// Nyaa search
-		go func() {
-			apiURL := fmt.Sprintf("https://nyaa.si/?page=rss&q=%s&c=1_2&f=0", url.QueryEscape(query))
-			resp, err := http.Get(apiURL)
-			if err != nil {
-				nyaaChan <- nil
-				return
-			}
-			defer resp.Body.Close()
-
-			var rss NyaaRSS
-			if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
-				nyaaChan <- nil
-				return
-			}
-
-			torrents := make([]Torrent, 0, len(rss.Channel.Items))
-			for i, item := range rss.Channel.Items {
-				t := item.toTorrent(i)
-				t.Source = "nyaa"  // <-- Add this line
-				torrents = append(torrents, t)
-}
-			nyaaChan <- torrents
-		}()
-
-		// Collect results
-		animetoshoResults := <-animetoshoChan
-		nyaaResults := <-nyaaChan
-
-		// Combine and deduplicate
-		combined := make([]Torrent, 0)
-		if animetoshoResults != nil {
-			combined = append(combined, animetoshoResults...)
-		}
-		if nyaaResults != nil {
-			combined = append(combined, nyaaResults...)
-		}
-
-		// Re-index
-		for i := range combined {
-			combined[i].ID = i
-		}
-
-		return torrentSearchResultMsg(combined)
+func (nyaaSource) Search(ctx context.Context, query string) ([]Torrent, error) {
+	apiURL := fmt.Sprintf("https://nyaa.si/?page=rss&q=%s&c=1_2&f=0", url.QueryEscape(query))
+	resp, err := getTorrentFeed(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var rss NyaaRSS
+	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
+		return nil, fmt.Errorf("decoding feed: %w", err)
+	}
+
+	torrents := make([]Torrent, 0, len(rss.Channel.Items))
+	for i, item := range rss.Channel.Items {
+		t := item.toTorrent(i)
+		t.Source = "nyaa"
+		torrents = append(torrents, t)
 	}
+	return torrents, nil
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// scoreFormat is the user's AniList score format. AniList sends and accepts
+// list scores in it, an empty format means nobody is logged in.
+type scoreFormat string
+
+const (
+	scorePoint100       scoreFormat = "POINT_100"
+	scorePoint10Decimal scoreFormat = "POINT_10_DECIMAL"
+	scorePoint10        scoreFormat = "POINT_10"
+	scorePoint5         scoreFormat = "POINT_5"
+	scorePoint3         scoreFormat = "POINT_3"
+)
+
+// Smileys of the 3 point format, for the scores 1 to 3
+var scoreSmileys = []string{"☹️", "😐", "🙂"}
+
+func (f scoreFormat) max() float64 {
+	switch f {
+	case scorePoint100:
+		return 100
+	case scorePoint5:
+		return 5
+	case scorePoint3:
+		return 3
+	}
+	return 10
+}
+
+// format renders a score for display, 0 means not scored
+func (f scoreFormat) format(score float64) string {
+	switch f {
+	case scorePoint100:
+		return fmt.Sprintf("⭐ %.0f/100", score)
+	case scorePoint10:
+		return fmt.Sprintf("⭐ %.0f/10", score)
+	case scorePoint5:
+		stars := int(math.Round(score))
+		return strings.Repeat("★", stars) + strings.Repeat("☆", 5-stars)
+	case scorePoint3:
+		if i := int(math.Round(score)); i >= 1 && i <= 3 {
+			return scoreSmileys[i-1]
+		}
+	}
+	return fmt.Sprintf("⭐ %.1f/10", score)
+}
+
+// hint describes what parse accepts
+func (f scoreFormat) hint() string {
+	switch f {
+	case scorePoint100:
+		return "0-100"
+	case scorePoint10:
+		return "0-10"
+	case scorePoint5:
+		return "0-5 stars"
+	case scorePoint3:
+		return "1 ☹️  2 😐 3 🙂, 0 for none"
+	}
+	return "0-10, decimals allowed"
+}
+
+// parse reads a score typed by the user, 0 clears it
+func (f scoreFormat) parse(s string) (float64, error) {
+	score, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(score) || score < 0 || score > f.max() {
+		return 0, fmt.Errorf("score must be %s", f.hint())
+	}
+	if f != scorePoint10Decimal && f != "" && score != math.Trunc(score) {
+		return 0, fmt.Errorf("score must be a whole number, %s", f.hint())
+	}
+	return score, nil
+}
+
+// animeTitle returns the title in the user's preferred title language
+func (m *model) animeTitle(a Anime) string {
+	return a.Title.Preferred(m.titleLanguage)
+}
+
+// Upper bound on the titles torrents are searched for at once
+const maxSearchTitles = 6
+
+// seasonSuffix matches the season at the end of a title, as in "Season 2",
+// "S2" or "2nd Season"
+var seasonSuffix = regexp.MustCompile(`(?i)[\s:-]+(?:season\s*(\d+)|s(\d+)|(\d+)(?:st|nd|rd|th)\s+season)$`)
+
+// searchTitles are the titles torrents are searched for. Release names use
+// the English or romaji title whatever the user reads, spelling the season in
+// different ways, and sometimes a synonym. Titles in other scripts never
+// match on the torrent sources.
+func searchTitles(a Anime) []string {
+	titles := []string{strings.TrimSpace(a.Title.English), strings.TrimSpace(a.Title.Romaji)}
+	candidates := append([]string{}, titles...)
+	for _, t := range titles {
+		candidates = append(candidates, seasonVariants(t)[1:]...)
+	}
+	candidates = append(candidates, a.Title.UserPreferred)
+	candidates = append(candidates, a.Synonyms...)
+
+	var queries []string
+	seen := map[string]bool{}
+	for _, t := range candidates {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] || !latinScript(t) || len(queries) == maxSearchTitles {
+			continue
+		}
+		seen[key] = true
+		queries = append(queries, t)
+	}
+	return queries
+}
+
+// latinScript reports whether every letter of s is a Latin one
+func latinScript(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// seasonVariants returns title followed by its other season spellings, each
+// once
+func seasonVariants(title string) []string {
+	match := seasonSuffix.FindStringSubmatchIndex(title)
+	if match == nil {
+		return []string{title}
+	}
+	var season string
+	for i := 2; i < len(match); i += 2 {
+		if match[i] >= 0 {
+			season = title[match[i]:match[i+1]]
+		}
+	}
+	n, _ := strconv.Atoi(season)
+	base := title[:match[0]]
+	variants := []string{title}
+	for _, v := range []string{
+		fmt.Sprintf("%s S%d", base, n),
+		fmt.Sprintf("%s Season %d", base, n),
+		fmt.Sprintf("%s %s Season", base, ordinal(n)),
+	} {
+		if !strings.EqualFold(v, title) {
+			variants = append(variants, v)
+		}
+	}
+	return variants
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// episodeQueries appends the episode number to every title
+func episodeQueries(titles []string, episode int) []string {
+	queries := make([]string, len(titles))
+	for i, t := range titles {
+		queries[i] = fmt.Sprintf("%s %02d", t, episode)
+	}
+	return queries
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeasonVariants(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Frieren", []string{"Frieren"}},
+		{"Oshi no Ko Season 2", []string{"Oshi no Ko Season 2", "Oshi no Ko S2", "Oshi no Ko 2nd Season"}},
+		{"Oshi no Ko S2", []string{"Oshi no Ko S2", "Oshi no Ko Season 2", "Oshi no Ko 2nd Season"}},
+		{"Oshi no Ko season 2", []string{"Oshi no Ko season 2", "Oshi no Ko S2", "Oshi no Ko 2nd Season"}},
+		{"Mushoku Tensei: 2nd season", []string{"Mushoku Tensei: 2nd season", "Mushoku Tensei S2", "Mushoku Tensei Season 2", "Mushoku Tensei 2nd Season"}},
+		{"Gintama 3rd Season", []string{"Gintama 3rd Season", "Gintama S3", "Gintama Season 3"}},
+		{"Title 11th Season", []string{"Title 11th Season", "Title S11", "Title Season 11"}},
+		{"Steins;Gate 0", []string{"Steins;Gate 0"}},
+		{"S2", []string{"S2"}},
+	}
+	for _, tt := range tests {
+		if got := seasonVariants(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("seasonVariants(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 22: "22nd", 101: "101st", 111: "111th"} {
+		if got := ordinal(n); got != want {
+			t.Errorf("ordinal(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestSearchTitles(t *testing.T) {
+	a := Anime{Synonyms: []string{"MT2", "無職転生 II", "Jobless Reincarnation 2", "Mushoku 2", "Mushoku Tensei 2nd Season"}}
+	a.Title.English = "Mushoku Tensei: Jobless Reincarnation Season 2"
+	a.Title.Romaji = "Mushoku Tensei II: Isekai Ittara Honki Dasu"
+	a.Title.UserPreferred = a.Title.Romaji
+	a.Title.Native = "無職転生 Ⅱ ～異世界行ったら本気だす～"
+
+	want := []string{
+		"Mushoku Tensei: Jobless Reincarnation Season 2",
+		"Mushoku Tensei II: Isekai Ittara Honki Dasu",
+		"Mushoku Tensei: Jobless Reincarnation S2",
+		"Mushoku Tensei: Jobless Reincarnation 2nd Season",
+		"MT2",
+		"Jobless Reincarnation 2",
+	}
+	if got := searchTitles(a); !reflect.DeepEqual(got, want) {
+		t.Errorf("searchTitles = %q, want %q", got, want)
+	}
+
+	romajiOnly := Anime{Title: Title{Romaji: "Sousou no Frieren", Native: "葬送のフリーレン"}}
+	if got := searchTitles(romajiOnly); !reflect.DeepEqual(got, []string{"Sousou no Frieren"}) {
+		t.Errorf("searchTitles = %q", got)
+	}
+}
+
+func TestEpisodeQueries(t *testing.T) {
+	got := episodeQueries([]string{"Frieren", "Sousou no Frieren"}, 7)
+	want := []string{"Frieren 07", "Sousou no Frieren 07"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScoreFormatParse(t *testing.T) {
+	tests := []struct {
+		format  scoreFormat
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{scorePoint100, "85", 85, false},
+		{scorePoint100, "100", 100, false},
+		{scorePoint100, "101", 0, true},
+		{scorePoint100, "85.5", 0, true},
+		{scorePoint10Decimal, "7.5", 7.5, false},
+		{scorePoint10Decimal, " 8 ", 8, false},
+		{scorePoint10Decimal, "10.1", 0, true},
+		{scorePoint10, "7", 7, false},
+		{scorePoint10, "7.5", 0, true},
+		{scorePoint5, "5", 5, false},
+		{scorePoint5, "6", 0, true},
+		{scorePoint3, "2", 2, false},
+		{scorePoint3, "4", 0, true},
+		{scorePoint3, "0", 0, false},
+		{"", "9.5", 9.5, false},
+		{scorePoint10, "-1", 0, true},
+		{scorePoint10, "NaN", 0, true},
+		{scorePoint10, "Inf", 0, true},
+		{scorePoint10, "ten", 0, true},
+		{scorePoint10, "", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.format.parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s.parse(%q) error = %v, want error %v", tt.format, tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s.parse(%q) = %v, want %v", tt.format, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+// Recommendations fetched per anime
+const recommendationsPerPage = 25
+
+// recommendationLevel is the list of recommendations for one anime. Drilling
+// into a recommendation pushes another level.
+type recommendationLevel struct {
+	source Anime
+	items  []Recommendation
+	cursor int
+}
+
+type recommendationsMsg struct {
+	source Anime
+	items  []Recommendation
+	err    error
+}
+
+func fetchRecommendations(client *anilist.Client, source Anime) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		items, err := client.Recommendations(ctx, source.ID, recommendationsPerPage)
+		return recommendationsMsg{source: source, items: items, err: err}
+	}
+}
+
+// openRecommendations loads the recommendations for source, on top of the
+// ones already shown when drilling down
+func (m *model) openRecommendations(source Anime) tea.Cmd {
+	if !m.onAniList(source) {
+		return nil
+	}
+	if m.mode != ModeRecommendations {
+		m.recReturnMode = m.mode
+		m.recStack = nil
+	}
+	m.loading = true
+	m.loadingMsg = "Loading recommendations..."
+	return tea.Batch(m.spinner.Tick, fetchRecommendations(m.aniList, source))
+}
+
+func (m *model) pushRecommendations(msg recommendationsMsg) {
+	m.loading = false
+	if msg.err != nil {
+		m.statusMsg = fmt.Sprintf("Failed to load recommendations: %v", msg.err)
+		return
+	}
+	if len(msg.items) == 0 {
+		m.statusMsg = fmt.Sprintf("No recommendations for %s yet", m.animeTitle(msg.source))
+		return
+	}
+	m.recStack = append(m.recStack, recommendationLevel{source: msg.source, items: msg.items})
+	m.mode = ModeRecommendations
+}
+
+// popRecommendations goes back one level, leaving the view after the first
+func (m *model) popRecommendations() {
+	if len(m.recStack) > 0 {
+		m.recStack = m.recStack[:len(m.recStack)-1]
+	}
+	if len(m.recStack) == 0 {
+		m.mode = m.recReturnMode
+	}
+}
+
+func (m *model) currentRecommendations() *recommendationLevel {
+	if len(m.recStack) == 0 {
+		return nil
+	}
+	return &m.recStack[len(m.recStack)-1]
+}
+
+// selectedRecommendation returns the anime under the cursor
+func (m *model) selectedRecommendation() (*Anime, bool) {
+	level := m.currentRecommendations()
+	if level == nil || level.cursor >= len(level.items) {
+		return nil, false
+	}
+	return level.items[level.cursor].Media, true
+}
+
+func (m *model) handleRecommendationKeys(msg tea.KeyMsg) tea.Cmd {
+	level := m.currentRecommendations()
+	if level == nil {
+		return nil
+	}
+	switch msg.String() {
+	case "up", "k":
+		if level.cursor > 0 {
+			level.cursor--
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+		}
+	case "down", "j":
+		if level.cursor < len(level.items)-1 {
+			level.cursor++
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+		}
+	case "right", "l":
+		// Drill into the recommendations of the selected anime
+		if anime, ok := m.selectedRecommendation(); ok {
+			return m.openRecommendations(*anime)
+		}
+	case "backspace", "left", "h":
+		m.popRecommendations()
+		m.viewport.SetContent(m.renderContent())
+		m.viewport.GotoTop()
+	case "w":
+		if anime, ok := m.selectedRecommendation(); ok {
+			return m.planToWatch(*anime)
+		}
+	case "enter":
+		if anime, ok := m.selectedRecommendation(); ok {
+			m.selectedAnime = anime
+			m.loading = true
+			m.loadingMsg = "looking for torrents..."
+			return tea.Batch(m.spinner.Tick, m.searchTorrents(searchTitles(*anime)...))
+		}
+	}
+	return nil
+}
+
+func (m *model) renderRecommendationsContent() string {
+	level := m.currentRecommendations()
+	if level == nil {
+		return "No recommendations."
+	}
+
+	leftWidth := m.viewport.Width / 2
+
+	var leftPanel strings.Builder
+	var trail []string
+	for _, l := range m.recStack {
+		trail = append(trail, m.animeTitle(l.source))
+	}
+	leftPanel.WriteString(wrapText("💡 Because of "+strings.Join(trail, " → "), leftWidth-2) + "\n\n")
+
+	for i, rec := range level.items {
+		cursor := "  "
+		if level.cursor == i {
+			cursor = "▶ "
+		}
+
+		title := m.animeTitle(*rec.Media)
+		maxLen := leftWidth - 20
+		if maxLen < 20 {
+			maxLen = 20
+		}
+		if len(title) > maxLen {
+			title = title[:maxLen-3] + "..."
+		}
+
+		mark := ""
+		if rec.Media.MediaListEntry != nil {
+			mark = " ✓"
+		}
+		leftPanel.WriteString(fmt.Sprintf("%s%s (👍 %d)%s\n", cursor, title, rec.Rating, mark))
+	}
+
+	var rightPanel string
+	if anime, ok := m.selectedRecommendation(); ok {
+		rightPanel = m.renderAnimePanel(anime)
+	}
+	return combinePanels(leftPanel.String(), rightPanel, m.viewport.Width)
+}
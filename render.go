@@ -3,27 +3,87 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 func (m *model) renderView() string {
 	if m.loading {
 		return fmt.Sprintf("\n\n   %s %s\n\n", m.spinner.View(), m.loadingMsg)
 	}
+	if m.mode == ModeLogin && m.passPrompt != promptNone {
+		label := map[passphrasePrompt]string{
+			promptUnlock:  "Passphrase",
+			promptNew:     "New passphrase (empty to store tokens unencrypted)",
+			promptConfirm: "Repeat passphrase",
+		}[m.passPrompt]
+		return fmt.Sprintf("\n\n  🎬 AniList Torrent Browser\n\n  %s\n\n  %s: %s\n\n  Enter: confirm | Esc: cancel\n\n",
+			m.loginMsg, label, m.passInput.View())
+	}
+	if m.mode == ModeLogin && m.pinMode {
+		return fmt.Sprintf("\n\n  🎬 AniList Torrent Browser\n\n  Open this URL in any browser, authorize and paste the token it shows:\n\n  %s\n\n  Token: %s\n\n  The AniList client's redirect URL must be %s\n  Enter: login | Esc: back\n\n",
+			pinAuthURL(), m.pinInput.View(), pinURL)
+	}
 	if m.mode == ModeLogin {
-		return fmt.Sprintf("\n\n  🎬 AniList Torrent Browser\n\n  %s\n\n", m.loginMsg)
+		return fmt.Sprintf("\n\n  🎬 AniList Torrent Browser\n\n  %s\n\n%s", m.loginMsg, m.renderProfiles())
 	}
 
 	if !m.ready {
 		return "\n  Loading..."
 	}
+	m.fitViewport()
 	return fmt.Sprintf("%s\n%s\n%s",
 		m.headerView(),
 		m.viewport.View(),
 		m.footerView())
 }
 
+// renderProfiles lists the saved accounts on the login screen
+func (m *model) renderProfiles() string {
+	if m.profiles.locked() {
+		return "  🔒 Profiles are encrypted, press 'e' to unlock them\n"
+	}
+	if len(m.profiles.Profiles) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	if m.profiles.encrypted() {
+		sb.WriteString("  🔒 Tokens are encrypted, press 'e' to change the passphrase\n\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("  ⚠ Tokens are saved unencrypted in ~/%s, press 'e' to set a passphrase\n\n", profilesFile))
+	}
+	sb.WriteString("  Profiles:\n")
+	for i, p := range m.profiles.Profiles {
+		if i >= 9 {
+			break
+		}
+		state := ""
+		switch {
+		case m.accessToken != "" && p.is(m.trackerName, m.userID):
+			state = " ✓ active"
+		case !p.LoggedIn():
+			state = " (logged out)"
+		}
+		if p.Tracker == trackerMyAnimeList {
+			state = " (MyAnimeList)" + state
+		}
+		sb.WriteString(fmt.Sprintf("  %d) %s%s\n", i+1, p.Username, state))
+	}
+	sb.WriteString("\n  1-9: switch profile\n")
+	return sb.String()
+}
+
 // View Components
 func (m *model) renderContent() string {
+	if m.entryEditor != nil {
+		return m.renderEntryEditor()
+	}
+	if m.filterForm != nil {
+		return m.renderFilterForm()
+	}
+	if m.importForm != nil {
+		return m.renderImportForm()
+	}
+
 	switch m.mode {
 	case ModeUserList:
 		return m.renderUserListContent()
@@ -31,6 +91,22 @@ func (m *model) renderContent() string {
 		return m.renderAnimeContent()
 	case ModeTorrents:
 		return m.renderTorrentContent()
+	case ModeDetail:
+		return m.renderDetailContent()
+	case ModeSchedule:
+		return m.renderScheduleContent()
+	case ModeRecommendations:
+		return m.renderRecommendationsContent()
+	case ModeFranchise:
+		return m.renderFranchiseContent()
+	case ModeSocial:
+		return m.renderSocialContent()
+	case ModeStats:
+		return m.renderStatsContent()
+	case ModeNotifications:
+		return m.renderNotificationsContent()
+	case ModeImport:
+		return m.renderImportContent()
 	}
 	return ""
 }
@@ -57,8 +133,13 @@ func (m *model) renderUserListContent() string {
 	var leftPanel strings.Builder
 
 	// List header
+	leftPanel.WriteString(m.renderTabBar() + "\n\n")
+
 	listTitle := m.currentListType.String()
-	if m.username != "" && (m.currentListType == ListCurrentlyWatching || m.currentListType == ListPlanToWatch) {
+	if m.currentListType == ListCustom {
+		listTitle = m.currentCustomList
+	}
+	if m.username != "" && m.currentListType.Personal() {
 		leftPanel.WriteString(fmt.Sprintf("👤 %s's %s\n\n", m.username, listTitle))
 	} else {
 		leftPanel.WriteString(fmt.Sprintf("📺 %s\n\n", listTitle))
@@ -70,10 +151,7 @@ func (m *model) renderUserListContent() string {
 			cursor = "▶ "
 		}
 
-		title := entry.Media.Title.English
-		if title == "" {
-			title = entry.Media.Title.Romaji
-		}
+		title := m.animeTitle(entry.Media)
 
 		// Truncate title based on left panel width
 		maxTitleWidth := leftWidth - 15
@@ -86,7 +164,7 @@ func (m *model) renderUserListContent() string {
 
 		// Show different info based on list type
 		var info string
-		if m.currentListType == ListCurrentlyWatching || m.currentListType == ListPlanToWatch {
+		if m.currentListType.Personal() {
 			episodes := "?"
 			if entry.Media.Episodes != nil {
 				episodes = fmt.Sprintf("%d", *entry.Media.Episodes)
@@ -114,17 +192,14 @@ func (m *model) renderUserListContent() string {
 		}
 
 		// Title
-		title := selectedEntry.Media.Title.English
-		if title == "" {
-			title = selectedEntry.Media.Title.Romaji
-		}
+		title := m.animeTitle(selectedEntry.Media)
 
 		// Wrap title if too long for right panel
 		wrappedTitle := wrapText(title, rightWidth)
 		rightPanel.WriteString(fmt.Sprintf("📺 %s\n\n", wrappedTitle))
 
-		// Show different details based on list type
-		if m.currentListType == ListCurrentlyWatching || m.currentListType == ListPlanToWatch {
+		// Show the user's own entry when the anime is on their list
+		if selectedEntry.Status != "" {
 			// User's personal data
 			episodes := "?"
 			if selectedEntry.Media.Episodes != nil {
@@ -133,7 +208,7 @@ func (m *model) renderUserListContent() string {
 			rightPanel.WriteString(fmt.Sprintf("Progress: %d/%s\n", selectedEntry.Progress, episodes))
 
 			if selectedEntry.Score > 0 {
-				rightPanel.WriteString(fmt.Sprintf("Your Score: ⭐ %.1f/10\n", selectedEntry.Score))
+				rightPanel.WriteString(fmt.Sprintf("Your Score: %s\n", m.scoreFormat.format(selectedEntry.Score)))
 			}
 
 			rightPanel.WriteString(fmt.Sprintf("Status: %s\n", selectedEntry.Status))
@@ -181,36 +256,15 @@ func (m *model) renderAnimeContent() string {
 		return "No anime found."
 	}
 
-	// Get selected anime for right panel
-	var selectedAnime *Anime
-	if m.animeCursor < len(m.anime) {
-		selectedAnime = &m.anime[m.animeCursor]
-	}
-
-	// Calculate dynamic sizes
 	leftWidth := m.viewport.Width / 2
-	rightWidth := m.viewport.Width - leftWidth - 3
-
-	// Image dimensions - MAXIMUM SIZE for best quality
-	imageWidth := rightWidth - 4
-	if imageWidth > 100 {
-		imageWidth = 100
-	}
-	if imageWidth < 40 {
-		imageWidth = 40
-	}
-
-	imageHeight := m.viewport.Height - 15
-	if imageHeight > 60 {
-		imageHeight = 60
-	}
-	if imageHeight < 30 {
-		imageHeight = 30
-	}
 
 	// Left panel - list
 	var leftPanel strings.Builder
-	leftPanel.WriteString("📺 Anime Search Results\n\n")
+	leftPanel.WriteString("📺 Anime Search Results\n")
+	if summary := filterSummary(m.searchFilter); summary != "" {
+		leftPanel.WriteString(wrapText("🎛  "+summary, leftWidth-2) + "\n")
+	}
+	leftPanel.WriteString("\n")
 
 	for i, a := range m.anime {
 		cursor := "  "
@@ -218,10 +272,7 @@ func (m *model) renderAnimeContent() string {
 			cursor = "▶ "
 		}
 
-		title := a.Title.English
-		if title == "" {
-			title = a.Title.Romaji
-		}
+		title := m.animeTitle(a)
 
 		// Truncate based on left width
 		maxLen := leftWidth - 20
@@ -242,52 +293,84 @@ func (m *model) renderAnimeContent() string {
 	}
 
 	// Right panel - details with image
+	var rightPanel string
+	if m.animeCursor < len(m.anime) {
+		rightPanel = m.renderAnimePanel(&m.anime[m.animeCursor])
+	}
+
+	// Combine panels side by side
+	return combinePanels(leftPanel.String(), rightPanel, m.viewport.Width)
+}
+
+// renderAnimePanel is the right hand panel with poster and details of an anime
+func (m *model) renderAnimePanel(selectedAnime *Anime) string {
+	leftWidth := m.viewport.Width / 2
+	rightWidth := m.viewport.Width - leftWidth - 3
+
+	// Image dimensions - MAXIMUM SIZE for best quality
+	imageWidth := rightWidth - 4
+	if imageWidth > 100 {
+		imageWidth = 100
+	}
+	if imageWidth < 40 {
+		imageWidth = 40
+	}
+
+	imageHeight := m.viewport.Height - 15
+	if imageHeight > 60 {
+		imageHeight = 60
+	}
+	if imageHeight < 30 {
+		imageHeight = 30
+	}
+
 	var rightPanel strings.Builder
-	if selectedAnime != nil {
-		// Render poster with dynamic sizing
-		if selectedAnime.CoverImage.Large != "" {
-			poster := getAnimePoster(selectedAnime.CoverImage.Large, imageWidth, imageHeight)
-			rightPanel.WriteString(poster)
-			rightPanel.WriteString("\n")
-		}
+	// Render poster with dynamic sizing
+	if selectedAnime.CoverImage.Large != "" {
+		poster := getAnimePoster(selectedAnime.CoverImage.Large, imageWidth, imageHeight)
+		rightPanel.WriteString(poster)
+		rightPanel.WriteString("\n")
+	}
 
-		// Title
-		title := selectedAnime.Title.English
-		if title == "" {
-			title = selectedAnime.Title.Romaji
-		}
-		wrappedTitle := wrapText(title, rightWidth)
-		rightPanel.WriteString(fmt.Sprintf("📺 %s\n\n", wrappedTitle))
+	// Title
+	title := m.animeTitle(*selectedAnime)
+	wrappedTitle := wrapText(title, rightWidth)
+	rightPanel.WriteString(fmt.Sprintf("📺 %s\n\n", wrappedTitle))
 
-		// Details
-		episodes := "?"
-		if selectedAnime.Episodes != nil {
-			episodes = fmt.Sprintf("%d", *selectedAnime.Episodes)
-		}
+	// Details
+	episodes := "?"
+	if selectedAnime.Episodes != nil {
+		episodes = fmt.Sprintf("%d", *selectedAnime.Episodes)
+	}
 
-		score := "N/A"
-		if selectedAnime.Score != nil {
-			score = fmt.Sprintf("%d%%", *selectedAnime.Score)
-		}
+	score := "N/A"
+	if selectedAnime.Score != nil {
+		score = fmt.Sprintf("%d%%", *selectedAnime.Score)
+	}
 
-		year := "?"
-		if selectedAnime.SeasonYear != nil {
-			year = fmt.Sprintf("%d", *selectedAnime.SeasonYear)
-		}
+	year := "?"
+	if selectedAnime.SeasonYear != nil {
+		year = fmt.Sprintf("%d", *selectedAnime.SeasonYear)
+	}
 
-		rightPanel.WriteString(fmt.Sprintf("Format: %s\n", selectedAnime.Format))
-		rightPanel.WriteString(fmt.Sprintf("Episodes: %s\n", episodes))
-		rightPanel.WriteString(fmt.Sprintf("Score: ⭐ %s\n", score))
-		rightPanel.WriteString(fmt.Sprintf("Season: %s %s\n", selectedAnime.Season, year))
-		rightPanel.WriteString(fmt.Sprintf("Status: %s\n\n", selectedAnime.Status))
+	rightPanel.WriteString(fmt.Sprintf("Format: %s\n", selectedAnime.Format))
+	rightPanel.WriteString(fmt.Sprintf("Episodes: %s\n", episodes))
+	rightPanel.WriteString(fmt.Sprintf("Score: ⭐ %s\n", score))
+	rightPanel.WriteString(fmt.Sprintf("Season: %s %s\n", selectedAnime.Season, year))
+	rightPanel.WriteString(fmt.Sprintf("Status: %s\n\n", selectedAnime.Status))
 
-		if selectedAnime.SiteURL != "" {
-			rightPanel.WriteString(fmt.Sprintf("🔗 %s\n", hyperlink("AniList", selectedAnime.SiteURL)))
+	if entry := selectedAnime.MediaListEntry; entry != nil {
+		rightPanel.WriteString(fmt.Sprintf("Your List: %s | Progress: %d/%s\n", entry.Status, entry.Progress, episodes))
+		if entry.Score > 0 {
+			rightPanel.WriteString(fmt.Sprintf("Your Score: %s\n", m.scoreFormat.format(entry.Score)))
 		}
+		rightPanel.WriteString("\n")
 	}
 
-	// Combine panels side by side
-	return combinePanels(leftPanel.String(), rightPanel.String(), m.viewport.Width)
+	if selectedAnime.SiteURL != "" {
+		rightPanel.WriteString(fmt.Sprintf("🔗 %s\n", hyperlink("AniList", selectedAnime.SiteURL)))
+	}
+	return rightPanel.String()
 }
 
 func (m *model) renderTorrentContent() string {
@@ -295,16 +378,16 @@ func (m *model) renderTorrentContent() string {
 	visible := m.visibleTorrents(perPage)
 
 	if len(visible) == 0 {
+		if m.torrentPending > 0 {
+			return "Searching..."
+		}
 		return "No torrents found for this anime."
 	}
 
 	var sb strings.Builder
 
 	if m.selectedAnime != nil {
-		title := m.selectedAnime.Title.English
-		if title == "" {
-			title = m.selectedAnime.Title.Romaji
-		}
+		title := m.animeTitle(*m.selectedAnime)
 		sb.WriteString(fmt.Sprintf("🎬 Torrents for: %s\n\n", title))
 	}
 
@@ -336,3 +419,116 @@ func (m *model) renderTorrentContent() string {
 	}
 	return sb.String()
 }
+
+func (m *model) renderDetailContent() string {
+	d := m.detail
+	if d == nil {
+		return "No details loaded."
+	}
+
+	leftWidth := m.viewport.Width/2 - 2
+	imageWidth := m.viewport.Width / 3
+	imageHeight := m.viewport.Height / 2
+
+	// Left panel - text details
+	var leftPanel strings.Builder
+
+	title := m.animeTitle(d.Media)
+	leftPanel.WriteString(fmt.Sprintf("📺 %s\n", wrapText(title, leftWidth)))
+	// The other titles below the preferred one
+	for _, other := range []string{d.Title.English, d.Title.Romaji, d.Title.Native} {
+		if other != "" && other != title {
+			leftPanel.WriteString(wrapText(other, leftWidth) + "\n")
+		}
+	}
+	leftPanel.WriteString("\n")
+
+	episodes := "?"
+	if d.Episodes != nil {
+		episodes = fmt.Sprintf("%d", *d.Episodes)
+	}
+	duration := ""
+	if d.Duration != nil {
+		duration = fmt.Sprintf(" × %d min", *d.Duration)
+	}
+	score := "N/A"
+	if d.Score != nil {
+		score = fmt.Sprintf("%d%%", *d.Score)
+	}
+
+	leftPanel.WriteString(fmt.Sprintf("Format: %s | Episodes: %s%s\n", d.Format, episodes, duration))
+	leftPanel.WriteString(fmt.Sprintf("Status: %s | Source: %s\n", d.Status, d.Source))
+	leftPanel.WriteString(fmt.Sprintf("Aired: %s → %s\n", d.StartDate, d.EndDate))
+	if d.Season != "" && d.SeasonYear != nil {
+		leftPanel.WriteString(fmt.Sprintf("Season: %s %d\n", d.Season, *d.SeasonYear))
+	}
+	leftPanel.WriteString(fmt.Sprintf("Avg Score: ⭐ %s\n", score))
+
+	if next := d.NextAiringEpisode; next != nil {
+		airing := time.Unix(next.AiringAt, 0).Format("Mon Jan 02 15:04")
+		leftPanel.WriteString(fmt.Sprintf("Next: Ep %d in %s (%s)\n", next.Episode, formatCountdown(next.AiringAt-time.Now().Unix()), airing))
+	}
+
+	if entry := d.MediaListEntry; entry != nil {
+		leftPanel.WriteString(fmt.Sprintf("Your List: %s | Progress: %d/%s\n", entry.Status, entry.Progress, episodes))
+	}
+
+	var studios []string
+	for _, s := range d.Studios.Nodes {
+		if s.IsAnimationStudio {
+			studios = append(studios, s.Name)
+		}
+	}
+	if len(studios) > 0 {
+		leftPanel.WriteString(wrapText("Studios: "+strings.Join(studios, ", "), leftWidth) + "\n")
+	}
+
+	if len(d.Genres) > 0 {
+		leftPanel.WriteString(wrapText("Genres: "+strings.Join(d.Genres, ", "), leftWidth) + "\n")
+	}
+
+	var tags []string
+	for _, t := range d.Tags {
+		if !t.IsMediaSpoiler && len(tags) < 10 {
+			tags = append(tags, fmt.Sprintf("%s (%d%%)", t.Name, t.Rank))
+		}
+	}
+	if len(tags) > 0 {
+		leftPanel.WriteString(wrapText("Tags: "+strings.Join(tags, ", "), leftWidth) + "\n")
+	}
+
+	if d.Description != "" {
+		leftPanel.WriteString("\n📝 Synopsis\n")
+		for _, para := range strings.Split(stripHTML(d.Description), "\n") {
+			leftPanel.WriteString(wrapText(para, leftWidth) + "\n")
+		}
+	}
+
+	if len(d.Relations.Edges) > 0 {
+		leftPanel.WriteString("\n🔗 Relations\n")
+		for _, edge := range d.Relations.Edges {
+			relTitle := m.animeTitle(edge.Node)
+			format := edge.Node.Format
+			if format == "" {
+				format = edge.Node.Type
+			}
+			leftPanel.WriteString(fmt.Sprintf("  %s: %s (%s)\n", edge.RelationType, relTitle, format))
+		}
+	}
+
+	// Right panel - poster and links
+	var rightPanel strings.Builder
+	if d.CoverImage.Large != "" {
+		rightPanel.WriteString(getAnimePoster(d.CoverImage.Large, imageWidth, imageHeight))
+		rightPanel.WriteString("\n")
+	}
+
+	if d.SiteURL != "" {
+		rightPanel.WriteString(fmt.Sprintf("🔗 %s\n", hyperlink("AniList", d.SiteURL)))
+	}
+	for _, link := range d.ExternalLinks {
+		rightPanel.WriteString(fmt.Sprintf("🔗 %s\n", hyperlink(link.Site, link.URL)))
+	}
+
+	return combinePanels(leftPanel.String(), rightPanel.String(), m.viewport.Width)
+}
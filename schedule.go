@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+// Days of already aired episodes shown before today
+const scheduleDaysBack = 7
+
+// Days of upcoming episodes shown from today
+const scheduleDaysAhead = 7
+
+type airingScheduleMsg struct {
+	schedules  []AiringSchedule
+	seasonWide bool
+	err        error
+}
+
+// fetchAiringSchedule loads the airing schedule around today for what the
+// user is watching or rewatching, or for everything airing this season when
+// seasonWide is set
+func fetchAiringSchedule(client *anilist.Client, userID int, seasonWide bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		var mediaIDs []int
+		var err error
+		if seasonWide {
+			season, year := getCurrentSeason(time.Now())
+			mediaIDs, err = client.SeasonMediaIDs(ctx, season, year)
+		} else {
+			mediaIDs, err = client.MediaListIDs(ctx, userID, "CURRENT", "REPEATING")
+		}
+		if err != nil {
+			return airingScheduleMsg{seasonWide: seasonWide, err: err}
+		}
+		if len(mediaIDs) == 0 {
+			return airingScheduleMsg{seasonWide: seasonWide}
+		}
+
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		from := today.AddDate(0, 0, -scheduleDaysBack)
+		to := today.AddDate(0, 0, scheduleDaysAhead)
+
+		schedules, err := client.AiringSchedules(ctx, mediaIDs, from, to)
+		if err != nil {
+			return airingScheduleMsg{seasonWide: seasonWide, err: err}
+		}
+
+		sort.SliceStable(schedules, func(i, j int) bool {
+			return schedules[i].AiringAt < schedules[j].AiringAt
+		})
+
+		return airingScheduleMsg{schedules: schedules, seasonWide: seasonWide}
+	}
+}
+
+// openSchedule switches to the airing schedule, loading it from AniList
+func (m *model) openSchedule(seasonWide bool) tea.Cmd {
+	if !m.aniListAccount() {
+		seasonWide = true
+	}
+	if m.mode != ModeSchedule {
+		m.scheduleReturnMode = m.mode
+	}
+	m.scheduleSeasonWide = seasonWide
+	m.loading = true
+	m.loadingMsg = "Loading airing schedule..."
+	return tea.Batch(m.spinner.Tick, fetchAiringSchedule(m.aniList, m.userID, seasonWide))
+}
+
+func (m *model) handleScheduleKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		if m.scheduleCursor > 0 {
+			m.scheduleCursor--
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+		}
+	case "down", "j":
+		if m.scheduleCursor < len(m.schedules)-1 {
+			m.scheduleCursor++
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+		}
+	case "t":
+		// Toggle between the user's list and the whole season
+		return m.openSchedule(!m.scheduleSeasonWide)
+	case "enter":
+		if m.scheduleCursor < len(m.schedules) {
+			s := m.schedules[m.scheduleCursor]
+			if s.AiringAt > time.Now().Unix() {
+				m.statusMsg = fmt.Sprintf("Episode %d hasn't aired yet", s.Episode)
+				m.viewport.SetContent(m.renderContent())
+				return nil
+			}
+			m.selectedAnime = &s.Media
+			m.loading = true
+			m.loadingMsg = "looking for torrents..."
+			return tea.Batch(m.spinner.Tick, m.searchTorrents(episodeQueries(searchTitles(s.Media), s.Episode)...))
+		}
+	}
+	return nil
+}
+
+// scheduleCursorLine returns the rendered line of the schedule cursor,
+// accounting for the day headings between entries
+func (m *model) scheduleCursorLine() int {
+	line := 0
+	lastDay := ""
+	for i, s := range m.schedules {
+		day := time.Unix(s.AiringAt, 0).Format("2006-01-02")
+		if day != lastDay {
+			line += 2
+			lastDay = day
+		}
+		if i == m.scheduleCursor {
+			break
+		}
+		line++
+	}
+	return line
+}
+
+func (m *model) renderScheduleContent() string {
+	if len(m.schedules) == 0 {
+		return "No episodes airing in this window."
+	}
+
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	tomorrow := now.AddDate(0, 0, 1).Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+
+	var sb strings.Builder
+	lastDay := ""
+	for i, s := range m.schedules {
+		airing := time.Unix(s.AiringAt, 0)
+
+		day := airing.Format("2006-01-02")
+		if day != lastDay {
+			label := airing.Format("Monday, Jan 02")
+			switch day {
+			case today:
+				label = "Today — " + label
+			case tomorrow:
+				label = "Tomorrow — " + label
+			case yesterday:
+				label = "Yesterday — " + label
+			}
+			sb.WriteString(fmt.Sprintf("\n📅 %s\n", label))
+			lastDay = day
+		}
+
+		cursor := "  "
+		if m.scheduleCursor == i {
+			cursor = "▶ "
+		}
+
+		title := m.animeTitle(s.Media)
+
+		var when string
+		if s.AiringAt > now.Unix() {
+			when = "in " + formatCountdown(s.AiringAt-now.Unix())
+		} else {
+			when = "aired " + formatTime(int(s.AiringAt))
+		}
+
+		// Flag aired episodes the user hasn't watched yet
+		badge := ""
+		if entry := s.Media.MediaListEntry; entry != nil && s.AiringAt <= now.Unix() && s.Episode > entry.Progress {
+			badge = " 🆕"
+		}
+
+		sb.WriteString(fmt.Sprintf("%s%s  %s — Ep %d  (%s)%s\n",
+			cursor, airing.Format("15:04"), title, s.Episode, when, badge))
+	}
+	return sb.String()
+}
@@ -1,13 +1,11 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sunnygitgud/sakuhaku/anilist"
 )
 
 // Debug logging to file
@@ -26,75 +24,20 @@ type animeSearchResultMsg struct {
 	page       int
 }
 
-type torrentSearchResultMsg []Torrent
-
-func performAnimeSearch(query string, page int) tea.Cmd {
+func performAnimeSearch(tracker Tracker, filter anilist.MediaFilter, page int) tea.Cmd {
 	return func() tea.Msg {
-		variables := map[string]any{
-			"search":  query,
-			"page":    page,
-			"perPage": 20,
-		}
-
-		requestBody := map[string]any{
-			"query": `
-			query ($search: String, $page: Int, $perPage: Int) {
-				Page(page: $page, perPage: $perPage) {
-					pageInfo {
-						total
-						perPage
-						currentPage
-						lastPage
-						hasNextPage
-					}
-					media(search: $search, type: ANIME, sort: POPULARITY_DESC) {
-						id
-						title {
-							romaji
-							english
-						}
-						format
-						status
-						episodes
-						averageScore
-						season
-						seasonYear
-						coverImage {
-							large
-						}
-						siteUrl
-					}
-				}
-			}
-			`,
-			"variables": variables,
-		}
+		ctx, cancel := aniListContext()
+		defer cancel()
 
-		jsonData, err := json.Marshal(requestBody)
+		result, err := tracker.Search(ctx, filter, page, 20)
 		if err != nil {
-			return animeSearchResultMsg{anime: nil}
-		}
-
-		resp, err := http.Post("https://graphql.anilist.co", "application/json", bytes.NewBuffer(jsonData))
-		if err != nil {
-			return animeSearchResultMsg{anime: nil}
-		}
-		defer resp.Body.Close()
-
-		var result AniListResponse
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			return animeSearchResultMsg{anime: nil}
+			return aniListErrorMsg{err: err}
 		}
 
 		return animeSearchResultMsg{
-			anime:      result.Data.Page.Media,
-			totalPages: result.Data.Page.PageInfo.LastPage,
+			anime:      result.Media,
+			totalPages: result.PageInfo.LastPage,
 			page:       page - 1,
 		}
 	}
 }
-
-// Search both AnimeTosho and Nyaa
-func performTorrentSearch(query string) tea.Cmd {
-	return performCombinedSearch(query)
-}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+// Filter form field order
+const (
+	filterSearch = iota
+	filterGenres
+	filterTags
+	filterYear
+	filterSeason
+	filterFormats
+	filterStatus
+	filterMinScore
+	filterSort
+)
+
+// "ANY" leaves a choice field unset
+var (
+	filterSeasons  = []string{"ANY", "WINTER", "SPRING", "SUMMER", "FALL"}
+	filterStatuses = []string{"ANY", "RELEASING", "FINISHED", "NOT_YET_RELEASED", "CANCELLED", "HIATUS"}
+	filterSorts    = []string{"POPULARITY_DESC", "SCORE_DESC", "TRENDING_DESC", "START_DATE_DESC", "START_DATE", "FAVOURITES_DESC", "TITLE_ROMAJI", "SEARCH_MATCH"}
+	mediaFormats   = []string{"TV", "TV_SHORT", "MOVIE", "SPECIAL", "OVA", "ONA", "MUSIC"}
+	mediaGenres    = []string{"Action", "Adventure", "Comedy", "Drama", "Ecchi", "Fantasy", "Hentai", "Horror", "Mahou Shoujo", "Mecha", "Music", "Mystery", "Psychological", "Romance", "Sci-Fi", "Slice of Life", "Sports", "Supernatural", "Thriller"}
+)
+
+func newFilterForm(f anilist.MediaFilter) *form {
+	year := ""
+	switch {
+	case f.YearFrom != 0 && f.YearFrom == f.YearTo:
+		year = strconv.Itoa(f.YearFrom)
+	case f.YearFrom != 0 || f.YearTo != 0:
+		year = fmt.Sprintf("%d-%d", f.YearFrom, f.YearTo)
+	}
+	minScore := ""
+	if f.MinScore > 0 {
+		minScore = strconv.Itoa(f.MinScore)
+	}
+
+	return newForm(
+		textField("Search", f.Search, "title, leave empty to browse"),
+		textField("Genres", strings.Join(f.Genres, ", "), "e.g. Mecha, Sci-Fi"),
+		textField("Tags", strings.Join(f.Tags, ", "), "e.g. Super Robot, Military"),
+		textField("Year", year, "2005, 2000-2009 or 2000s"),
+		choiceField("Season", filterSeasons, f.Season),
+		textField("Format", strings.Join(f.Formats, ", "), strings.Join(mediaFormats, ", ")),
+		choiceField("Status", filterStatuses, f.Status),
+		textField("Min score", minScore, "average score above, 0-100"),
+		choiceField("Sort", filterSorts, f.Sort),
+	)
+}
+
+// filterFromForm validates the form and turns it into a search filter
+func filterFromForm(fm *form) (anilist.MediaFilter, error) {
+	var f anilist.MediaFilter
+	f.Search = fm.value(filterSearch)
+
+	for _, g := range splitList(fm.value(filterGenres)) {
+		genre, ok := matchFold(mediaGenres, g)
+		if !ok {
+			return f, fmt.Errorf("unknown genre %q", g)
+		}
+		f.Genres = append(f.Genres, genre)
+	}
+
+	f.Tags = splitList(fm.value(filterTags))
+
+	from, to, err := parseYearRange(fm.value(filterYear))
+	if err != nil {
+		return f, err
+	}
+	f.YearFrom, f.YearTo = from, to
+
+	if season := fm.value(filterSeason); season != "ANY" {
+		f.Season = season
+	}
+
+	for _, v := range splitList(fm.value(filterFormats)) {
+		format, ok := matchFold(mediaFormats, strings.ReplaceAll(v, " ", "_"))
+		if !ok {
+			return f, fmt.Errorf("unknown format %q", v)
+		}
+		f.Formats = append(f.Formats, format)
+	}
+
+	if status := fm.value(filterStatus); status != "ANY" {
+		f.Status = status
+	}
+
+	if v := fm.value(filterMinScore); v != "" {
+		score, err := strconv.Atoi(v)
+		if err != nil || score < 0 || score > 100 {
+			return f, fmt.Errorf("min score must be a number between 0 and 100")
+		}
+		f.MinScore = score
+	}
+
+	f.Sort = fm.value(filterSort)
+	return f, nil
+}
+
+// parseYearRange accepts "2005", "2000-2009" and "2000s"
+func parseYearRange(s string) (int, int, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	if decade, ok := strings.CutSuffix(s, "s"); ok {
+		year, err := strconv.Atoi(decade)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid year %q", s)
+		}
+		return year, year + 9, nil
+	}
+	if from, to, ok := strings.Cut(s, "-"); ok {
+		fromYear, err1 := strconv.Atoi(strings.TrimSpace(from))
+		toYear, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || fromYear > toYear {
+			return 0, 0, fmt.Errorf("invalid year range %q", s)
+		}
+		return fromYear, toYear, nil
+	}
+	year, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid year %q", s)
+	}
+	return year, year, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// matchFold returns the entry of options equal to s ignoring case
+func matchFold(options []string, s string) (string, bool) {
+	for _, o := range options {
+		if strings.EqualFold(o, s) {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+// filterSummary describes the active filters in one line
+func filterSummary(f anilist.MediaFilter) string {
+	var parts []string
+	if len(f.Genres) > 0 {
+		parts = append(parts, "Genres: "+strings.Join(f.Genres, ", "))
+	}
+	if len(f.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(f.Tags, ", "))
+	}
+	switch {
+	case f.YearFrom != 0 && f.YearFrom == f.YearTo:
+		parts = append(parts, fmt.Sprintf("Year: %d", f.YearFrom))
+	case f.YearFrom != 0 || f.YearTo != 0:
+		parts = append(parts, fmt.Sprintf("Years: %d-%d", f.YearFrom, f.YearTo))
+	}
+	if f.Season != "" {
+		parts = append(parts, "Season: "+f.Season)
+	}
+	if len(f.Formats) > 0 {
+		parts = append(parts, "Format: "+strings.Join(f.Formats, ", "))
+	}
+	if f.Status != "" {
+		parts = append(parts, "Status: "+f.Status)
+	}
+	if f.MinScore > 0 {
+		parts = append(parts, fmt.Sprintf("Score > %d", f.MinScore))
+	}
+	if f.Sort != "" && f.Sort != "POPULARITY_DESC" {
+		parts = append(parts, "Sort: "+f.Sort)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (m *model) handleFilterKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.filterForm = nil
+		m.filterErr = ""
+	case "ctrl+r":
+		m.filterForm = newFilterForm(anilist.MediaFilter{})
+		m.filterErr = ""
+	case "enter":
+		filter, err := filterFromForm(m.filterForm)
+		if err != nil {
+			m.filterErr = err.Error()
+			break
+		}
+		m.filterForm = nil
+		m.filterErr = ""
+		m.searchFilter = filter
+		m.mode = ModeAnimeSearch
+		m.loading = true
+		m.loadingMsg = "Searching anime..."
+		return tea.Batch(m.spinner.Tick, performAnimeSearch(m.listTracker(), m.searchFilter, 1))
+	default:
+		m.filterForm.update(msg)
+	}
+	m.viewport.SetContent(m.renderContent())
+	return nil
+}
+
+func (m *model) renderFilterForm() string {
+	var sb strings.Builder
+	sb.WriteString("🎛  Search Filters\n\n")
+	sb.WriteString(m.filterForm.view())
+	if m.filterErr != "" {
+		sb.WriteString(fmt.Sprintf("\n⚠ %s\n", m.filterErr))
+	}
+	return sb.String()
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestParseYearRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		from, to int
+		wantErr  bool
+	}{
+		{"", 0, 0, false},
+		{"2020", 2020, 2020, false},
+		{"2010-2015", 2010, 2015, false},
+		{"2010 - 2015", 2010, 2015, false},
+		{"1990s", 1990, 1999, false},
+		{"2015-2010", 0, 0, true},
+		{"20x0", 0, 0, true},
+		{"abcs", 0, 0, true},
+		{"2010-", 0, 0, true},
+	}
+	for _, tt := range tests {
+		from, to, err := parseYearRange(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseYearRange(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if from != tt.from || to != tt.to {
+			t.Errorf("parseYearRange(%q) = %d, %d, want %d, %d", tt.in, from, to, tt.from, tt.to)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+// Activities fetched per page of the following feed
+const activitiesPerPage = 30
+
+type activitiesMsg struct {
+	activities  []ListActivity
+	page        int
+	hasNextPage bool
+	err         error
+}
+
+// followedListsMsg carries the list entries followed users have for an anime
+type followedListsMsg struct {
+	mediaID int
+	lists   []UserMediaList
+	err     error
+}
+
+func fetchActivities(client *anilist.Client, page int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		result, err := client.FollowingActivities(ctx, page, activitiesPerPage)
+		if err != nil {
+			return activitiesMsg{page: page, err: err}
+		}
+		return activitiesMsg{
+			activities:  result.Activities,
+			page:        page,
+			hasNextPage: result.PageInfo.HasNextPage,
+		}
+	}
+}
+
+// fetchFollowedLists loads the followed users' entries with scores in the
+// viewer's score format
+func fetchFollowedLists(client *anilist.Client, userID, mediaID int, format scoreFormat) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		lists, err := followedLists(ctx, client, userID, mediaID, format)
+		return followedListsMsg{mediaID: mediaID, lists: lists, err: err}
+	}
+}
+
+func followedLists(ctx context.Context, client *anilist.Client, userID, mediaID int, format scoreFormat) ([]UserMediaList, error) {
+	following, err := client.Following(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(following))
+	for i, u := range following {
+		ids[i] = u.ID
+	}
+	if format == "" {
+		format = scorePoint10Decimal
+	}
+	return client.MediaListsOf(ctx, mediaID, ids, string(format))
+}
+
+// openSocial switches to the feed of the users the viewer follows
+func (m *model) openSocial() tea.Cmd {
+	if !m.requireAniList("see what the people you follow are watching") {
+		return nil
+	}
+	if m.mode != ModeSocial {
+		m.socialReturnMode = m.mode
+	}
+	m.loading = true
+	m.loadingMsg = "Loading activity..."
+	return tea.Batch(m.spinner.Tick, revalidate(m.aniList, func(c *anilist.Client) tea.Cmd {
+		return fetchActivities(c, 1)
+	}))
+}
+
+// setActivities shows a page of the feed. A refresh of the first page keeps
+// the cursor on the same activity when it is still there.
+func (m *model) setActivities(msg activitiesMsg) tea.Cmd {
+	// Nothing cached yet, the request to AniList is still running
+	if errors.Is(msg.err, anilist.ErrNotCached) {
+		return nil
+	}
+	m.loading = false
+	m.activityLoadingMore = false
+	if msg.err != nil {
+		m.statusMsg = fmt.Sprintf("Failed to load activity: %v", msg.err)
+		return nil
+	}
+
+	if msg.page <= 1 {
+		selectedID := 0
+		if a, ok := m.selectedActivity(); ok {
+			selectedID = a.ID
+		}
+		m.activities = msg.activities
+		m.activityCursor = 0
+		for i, a := range m.activities {
+			if a.ID == selectedID {
+				m.activityCursor = i
+			}
+		}
+	} else {
+		m.activities = append(m.activities, msg.activities...)
+	}
+	m.activityPage = msg.page
+	m.activityHasNextPage = msg.hasNextPage
+	m.mode = ModeSocial
+	return m.loadFollowedLists()
+}
+
+func (m *model) selectedActivity() (ListActivity, bool) {
+	if m.activityCursor < len(m.activities) {
+		return m.activities[m.activityCursor], true
+	}
+	return ListActivity{}, false
+}
+
+// loadFollowedLists fetches the followed users' entries for the selected
+// anime unless they are loaded already
+func (m *model) loadFollowedLists() tea.Cmd {
+	a, ok := m.selectedActivity()
+	if !ok {
+		return nil
+	}
+	if m.followedLists == nil {
+		m.followedLists = map[int][]UserMediaList{}
+	}
+	if _, loaded := m.followedLists[a.Media.ID]; loaded {
+		return nil
+	}
+	// Mark them as loading so moving back and forth doesn't ask again
+	m.followedLists[a.Media.ID] = nil
+	return fetchFollowedLists(m.aniList, m.userID, a.Media.ID, m.scoreFormat)
+}
+
+func (m *model) setFollowedLists(msg followedListsMsg) {
+	// The feed was refreshed while these were loading
+	if m.followedLists == nil {
+		return
+	}
+	if msg.err != nil {
+		delete(m.followedLists, msg.mediaID)
+		m.statusMsg = fmt.Sprintf("Failed to load followed users' lists: %v", msg.err)
+		return
+	}
+	// nil marks lists that are still loading
+	if msg.lists == nil {
+		msg.lists = []UserMediaList{}
+	}
+	m.followedLists[msg.mediaID] = msg.lists
+}
+
+// refreshActivities reloads the feed from AniList, ignoring the cache
+func (m *model) refreshActivities() tea.Cmd {
+	m.followedLists = nil
+	m.loading = true
+	m.loadingMsg = "Refreshing activity..."
+	return tea.Batch(m.spinner.Tick, fetchActivities(m.aniList.WithCachePolicy(anilist.NetworkFirst), 1))
+}
+
+func (m *model) handleSocialKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		if m.activityCursor > 0 {
+			m.activityCursor--
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+			return m.loadFollowedLists()
+		}
+	case "down", "j":
+		if m.activityCursor < len(m.activities)-1 {
+			m.activityCursor++
+			m.viewport.SetContent(m.renderContent())
+			m.ensureCursorVisible(1)
+			return tea.Batch(m.loadFollowedLists(), m.loadMoreActivities())
+		}
+	case "w":
+		if a, ok := m.selectedActivity(); ok {
+			return m.planToWatch(*a.Media)
+		}
+	case "enter":
+		if a, ok := m.selectedActivity(); ok {
+			m.selectedAnime = a.Media
+			m.loading = true
+			m.loadingMsg = "looking for torrents..."
+			return tea.Batch(m.spinner.Tick, m.searchTorrents(searchTitles(*a.Media)...))
+		}
+	}
+	return nil
+}
+
+// loadMoreActivities requests the next page once the cursor reaches the end
+func (m *model) loadMoreActivities() tea.Cmd {
+	if !m.activityHasNextPage || m.activityLoadingMore || m.activityCursor < len(m.activities)-1 {
+		return nil
+	}
+	m.activityLoadingMore = true
+	return fetchActivities(m.aniList, m.activityPage+1)
+}
+
+// activityText describes an activity the way AniList's feed does
+func (m *model) activityText(a ListActivity) string {
+	text := a.User.Name + " " + a.Status
+	if a.Progress != "" {
+		text += " " + a.Progress + " of"
+	}
+	return text + " " + m.animeTitle(*a.Media)
+}
+
+// listStatusText names a list status from the perspective of its owner
+func listStatusText(status string) string {
+	switch status {
+	case "CURRENT":
+		return "Watching"
+	case "PLANNING":
+		return "Planning"
+	case "COMPLETED":
+		return "Completed"
+	case "PAUSED":
+		return "Paused"
+	case "DROPPED":
+		return "Dropped"
+	case "REPEATING":
+		return "Rewatching"
+	}
+	return status
+}
+
+func (m *model) renderSocialContent() string {
+	if len(m.activities) == 0 {
+		return "Nobody you follow has updated their list lately."
+	}
+
+	leftWidth := m.viewport.Width / 2
+	var leftPanel strings.Builder
+	leftPanel.WriteString("👥 Following\n\n")
+	for i, a := range m.activities {
+		cursor := "  "
+		if i == m.activityCursor {
+			cursor = "▶ "
+		}
+		text := fmt.Sprintf("%-8s %s", formatTime(int(a.CreatedAt)), m.activityText(a))
+		if maxLen := leftWidth - 6; maxLen > 20 && len([]rune(text)) > maxLen {
+			text = string([]rune(text)[:maxLen-3]) + "..."
+		}
+		leftPanel.WriteString(fmt.Sprintf("%s%s %s\n", cursor, listStatusIcon(a.Media.MediaListEntry), text))
+	}
+	if m.activityLoadingMore {
+		leftPanel.WriteString("\n  Loading more...\n")
+	}
+
+	a, _ := m.selectedActivity()
+	var rightPanel strings.Builder
+	rightPanel.WriteString(m.renderAnimePanel(a.Media))
+	rightPanel.WriteString("\n👥 People you follow\n")
+	lists := m.followedLists[a.Media.ID]
+	switch {
+	case lists == nil:
+		rightPanel.WriteString("Loading...\n")
+	case len(lists) == 0:
+		rightPanel.WriteString("Nobody you follow has this on their list.\n")
+	}
+	for _, l := range lists {
+		line := fmt.Sprintf("%s: %s", l.User.Name, listStatusText(l.Status))
+		if l.Progress > 0 {
+			line += fmt.Sprintf(" | Ep %d", l.Progress)
+		}
+		if l.Score > 0 {
+			line += " | " + m.scoreFormat.format(l.Score)
+		}
+		rightPanel.WriteString(line + "\n")
+	}
+	return combinePanels(leftPanel.String(), rightPanel.String(), m.viewport.Width)
+}
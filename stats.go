@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sunnygitgud/sakuhaku/anilist"
+)
+
+var (
+	barStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	barTrackStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	statLabel     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// Block characters from 1/8 to 8/8 of a cell
+var (
+	verticalBlocks   = []rune("▁▂▃▄▅▆▇█")
+	horizontalBlocks = []rune("▏▎▍▌▋▊▉█")
+)
+
+// Rows of the score histogram
+const histogramHeight = 8
+
+type statisticsMsg struct {
+	stats *AnimeStatistics
+	err   error
+}
+
+func fetchStatistics(client *anilist.Client, userID int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := aniListContext()
+		defer cancel()
+
+		stats, err := client.AnimeStatistics(ctx, userID)
+		return statisticsMsg{stats: stats, err: err}
+	}
+}
+
+// openStats switches to the statistics dashboard of the logged in user
+func (m *model) openStats() tea.Cmd {
+	if !m.requireAniList("see your statistics") {
+		return nil
+	}
+	if m.mode != ModeStats {
+		m.statsReturnMode = m.mode
+	}
+	m.loading = true
+	m.loadingMsg = "Crunching your numbers..."
+	return tea.Batch(m.spinner.Tick, fetchStatistics(m.aniList, m.userID))
+}
+
+// barRow is one labelled bar of a bar chart
+type barRow struct {
+	label string
+	value int
+	note  string
+}
+
+// barChart draws horizontal bars scaled to the largest value, in eighths of
+// a cell
+func barChart(rows []barRow, width int) string {
+	maxValue, labelWidth := 0, 0
+	for _, r := range rows {
+		maxValue = max(maxValue, r.value)
+		labelWidth = max(labelWidth, len([]rune(r.label)))
+	}
+	if maxValue == 0 {
+		return ""
+	}
+	labelWidth = min(labelWidth, 20)
+	barWidth := max(10, width-labelWidth-24)
+
+	var sb strings.Builder
+	for _, r := range rows {
+		eighths := r.value * barWidth * 8 / maxValue
+		bar := strings.Repeat("█", eighths/8)
+		if rest := eighths % 8; rest > 0 {
+			bar += string(horizontalBlocks[rest-1])
+		}
+		track := strings.Repeat("─", barWidth-len([]rune(bar)))
+		sb.WriteString(fmt.Sprintf("  %-*s %s%s %5d %s\n",
+			labelWidth, truncateToWidth(r.label, labelWidth),
+			barStyle.Render(bar), barTrackStyle.Render(track), r.value, statLabel.Render(r.note)))
+	}
+	return sb.String()
+}
+
+// histogram draws one vertical bar per value with its label below
+func histogram(labels []string, values []int, height int) string {
+	maxValue, colWidth := 0, 3
+	for i, v := range values {
+		maxValue = max(maxValue, v)
+		colWidth = max(colWidth, len(labels[i])+1)
+	}
+	if maxValue == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	for row := height - 1; row >= 0; row-- {
+		sb.WriteString("  ")
+		for _, v := range values {
+			eighths := v*height*8/maxValue - row*8
+			cell := " "
+			switch {
+			case eighths >= 8:
+				cell = "█"
+			case eighths > 0:
+				cell = string(verticalBlocks[eighths-1])
+			}
+			sb.WriteString(barStyle.Render(strings.Repeat(cell, colWidth-1)) + " ")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("  ")
+	for _, l := range labels {
+		sb.WriteString(statLabel.Render(fmt.Sprintf("%-*s", colWidth, l)))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// sparkline draws values as a single line of block characters
+func sparkline(values []int) string {
+	maxValue := 0
+	for _, v := range values {
+		maxValue = max(maxValue, v)
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		if maxValue == 0 || v == 0 {
+			sb.WriteString(" ")
+			continue
+		}
+		sb.WriteRune(verticalBlocks[max(0, v*len(verticalBlocks)/maxValue-1)])
+	}
+	return barStyle.Render(sb.String())
+}
+
+func (m *model) renderStatsContent() string {
+	s := m.stats
+	if s == nil {
+		return "No statistics loaded."
+	}
+	if s.Count == 0 {
+		return "Your anime list is empty, there is nothing to count yet."
+	}
+	width := m.viewport.Width
+
+	var sb strings.Builder
+	sb.WriteString("📊 Overview\n\n")
+	sb.WriteString(fmt.Sprintf("  %s %d   %s %d   %s %.1f   %s %.1f (σ %.1f)\n\n",
+		statLabel.Render("Anime"), s.Count,
+		statLabel.Render("Episodes"), s.EpisodesWatched,
+		statLabel.Render("Days watched"), float64(s.MinutesWatched)/(24*60),
+		statLabel.Render("Mean score"), s.MeanScore, s.StandardDeviation))
+
+	if len(s.Scores) > 0 {
+		var labels []string
+		var values []int
+		for _, sc := range s.Scores {
+			labels = append(labels, fmt.Sprintf("%d", sc.Score))
+			values = append(values, sc.Count)
+		}
+		sb.WriteString("⭐ Score distribution\n\n")
+		sb.WriteString(histogram(labels, values, histogramHeight) + "\n")
+	}
+
+	if len(s.Genres) > 0 {
+		var rows []barRow
+		for _, g := range s.Genres {
+			rows = append(rows, barRow{label: g.Genre, value: g.Count, note: fmt.Sprintf("⭐ %.1f", g.MeanScore)})
+		}
+		sb.WriteString("🎭 Top genres\n\n")
+		sb.WriteString(barChart(rows, width) + "\n")
+	}
+
+	if len(s.Formats) > 0 {
+		var rows []barRow
+		for _, f := range s.Formats {
+			rows = append(rows, barRow{label: f.Format, value: f.Count})
+		}
+		sb.WriteString("🎞  Formats\n\n")
+		sb.WriteString(barChart(rows, width) + "\n")
+	}
+
+	if n := len(s.ReleaseYears); n > 0 {
+		first, last := s.ReleaseYears[0].ReleaseYear, s.ReleaseYears[n-1].ReleaseYear
+		perYear := make([]int, last-first+1)
+		for _, y := range s.ReleaseYears {
+			perYear[y.ReleaseYear-first] = y.Count
+		}
+		sb.WriteString("📅 Release years\n\n")
+		sb.WriteString(fmt.Sprintf("  %d %s %d\n\n", first, sparkline(perYear), last))
+	}
+
+	if len(s.Studios) > 0 {
+		var rows []barRow
+		for _, st := range s.Studios {
+			rows = append(rows, barRow{label: st.Studio.Name, value: st.Count, note: fmt.Sprintf("⭐ %.1f", st.MeanScore)})
+		}
+		sb.WriteString("🏢 Top studios\n\n")
+		sb.WriteString(barChart(rows, width))
+	}
+	return sb.String()
+}
@@ -21,9 +21,18 @@ func openVideoPlayer(streamURL string) tea.Cmd {
 		players := []string{"mpv", "vlc", "ffplay", "mplayer"}
 
 		for _, player := range players {
-			cmd := exec.Command(player, streamURL)
+			args := []string{streamURL}
+			ipcPath := ""
+			if player == "mpv" {
+				// Expose mpv's JSON IPC so playback can be followed
+				ipcPath = newMpvIPCPath()
+				args = append([]string{"--input-ipc-server=" + ipcPath}, args...)
+			}
+
+			cmd := exec.Command(player, args...)
 			if err := cmd.Start(); err == nil {
-				return videoPlayerOpenedMsg{player: player, url: streamURL}
+				go cmd.Wait()
+				return videoPlayerOpenedMsg{player: player, url: streamURL, ipcPath: ipcPath}
 			}
 		}
 
@@ -34,6 +43,39 @@ func openVideoPlayer(streamURL string) tea.Cmd {
 }
 
 type videoPlayerOpenedMsg struct {
-	player string
-	url    string
+	player  string
+	url     string
+	ipcPath string
+}
+
+// syncWatchedEpisode bumps the list progress of the session's anime to the
+// episode that was just watched, completing the entry on the final episode
+func (m *model) syncWatchedEpisode(session playbackSession) tea.Cmd {
+	episode := parseEpisodeNumber(session.file)
+	if episode == 0 && session.anime.Episodes != nil && *session.anime.Episodes == 1 {
+		episode = 1
+	}
+	if episode == 0 {
+		m.statusMsg = "Watched, but couldn't tell the episode number from the file name"
+		return nil
+	}
+
+	prev, ok := m.findEntry(session.anime.ID)
+	if !ok {
+		prev = entryFromMedia(session.anime)
+	}
+	if episode <= prev.Progress {
+		return nil
+	}
+
+	next := prev
+	next.Progress = episode
+	if total := prev.Media.Episodes; total != nil && episode >= *total {
+		next.Progress = *total
+		next.Status = "COMPLETED"
+	} else if next.Status != "REPEATING" {
+		next.Status = "CURRENT"
+	}
+
+	return m.updateListEntry(prev, next)
 }
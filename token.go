@@ -2,64 +2,303 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"time"
+
+	"github.com/sunnygitgud/sakuhaku/anilist"
+	"github.com/sunnygitgud/sakuhaku/mal"
 )
 
 //toeken presistence
 
+// profilesFile holds every saved account. It replaced tokenFile, which is
+// migrated on first start.
+const profilesFile = ".anilist_profiles"
+
+// profile is one saved account
+type profile struct {
+	AccessToken  string             `json:"access_token"`
+	Username     string             `json:"username"`
+	UserID       int                `json:"user_id"`
+	Tracker      string             `json:"tracker,omitempty"`       // empty on profiles saved before there was a choice, which are AniList
+	RefreshToken string             `json:"refresh_token,omitempty"` // only MyAnimeList tokens are refreshed
+	ExpiresAt    int64              `json:"expires_at,omitempty"`    // unix seconds, 0 when unknown
+	Preferences  profilePreferences `json:"preferences"`
+}
+
+// profilePreferences are per account settings. Zero values fall back to .env.
+type profilePreferences struct {
+	WatchThreshold int `json:"watch_threshold,omitempty"`
+}
+
+// LoggedIn reports whether the profile still holds a token
+func (p *profile) LoggedIn() bool {
+	return p.AccessToken != ""
+}
+
+// token returns the saved token of p
+func (p *profile) token() authToken {
+	t := authToken{AccessToken: p.AccessToken, RefreshToken: p.RefreshToken}
+	if p.ExpiresAt > 0 {
+		t.Expiry = time.Unix(p.ExpiresAt, 0)
+	}
+	return t
+}
+
+// setToken saves t in p
+func (p *profile) setToken(t authToken) {
+	p.AccessToken = t.AccessToken
+	p.RefreshToken = t.RefreshToken
+	p.ExpiresAt = 0
+	if !t.Expiry.IsZero() {
+		p.ExpiresAt = t.Expiry.Unix()
+	}
+}
+
+// is reports whether p is the account userID on tracker. User IDs are only
+// unique per tracker.
+func (p *profile) is(tracker string, userID int) bool {
+	return p.UserID == userID && profileTracker(p.Tracker) == profileTracker(tracker)
+}
+
+// profileTracker names the tracker of a profile, which is AniList for
+// profiles saved without one
+func profileTracker(name string) string {
+	if name == "" {
+		return trackerAniList
+	}
+	return name
+}
+
+// profileStore is the content of profilesFile. With a passphrase the file
+// holds a sealedStore instead, which has to be unlocked before use.
+type profileStore struct {
+	Active        int       `json:"active"`                   // user ID of the active profile, 0 for none
+	ActiveTracker string    `json:"active_tracker,omitempty"` // tracker of the active profile, empty for AniList
+	Profiles      []profile `json:"profiles"`
+
+	key    *storeKey    // set when the file is encrypted
+	sealed *sealedStore // set while the encrypted file is still locked
+}
+
+// savedToken is the format of the old single account tokenFile
 type savedToken struct {
 	AccessToken string `json:"access_token"`
 	Username    string `json:"username"`
 	UserID      int    `json:"user_id"`
 }
 
-func saveToken(token, username string, userID int) error {
+func homePath(name string) (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return err
+		return "", err
 	}
+	return fmt.Sprintf("%s/%s", homeDir, name), nil
+}
 
-	tokenPath := fmt.Sprintf("%s/%s", homeDir, tokenFile)
-
-	data := savedToken{
-		AccessToken: token,
-		Username:    username,
-		UserID:      userID,
+// loadProfiles reads the saved profiles, migrating tokenFile if there is one
+func loadProfiles() (*profileStore, error) {
+	store := &profileStore{}
+	path, err := homePath(profilesFile)
+	if err != nil {
+		return store, err
 	}
 
-	jsonData, err := json.Marshal(data)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, store.migrateTokenFile()
+	}
 	if err != nil {
-		return err
+		return store, err
+	}
+	if isSealed(data) {
+		store.sealed = &sealedStore{}
+		return store, json.Unmarshal(data, store.sealed)
 	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return &profileStore{}, fmt.Errorf("reading %s: %w", profilesFile, err)
+	}
+	return store, nil
+}
 
-	return os.WriteFile(tokenPath, jsonData, 0600)
+// locked reports whether the profiles are encrypted and not unlocked yet
+func (s *profileStore) locked() bool {
+	return s.sealed != nil
 }
 
-func loadSavedToken() (string, string, int, error) {
-	homeDir, err := os.UserHomeDir()
+// encrypted reports whether the profiles are saved with a passphrase
+func (s *profileStore) encrypted() bool {
+	return s.key != nil || s.sealed != nil
+}
+
+// unlock decrypts the profiles with passphrase
+func (s *profileStore) unlock(passphrase string) error {
+	plaintext, key, err := s.sealed.open(passphrase)
 	if err != nil {
-		return "", "", 0, err
+		return err
+	}
+	if err := json.Unmarshal(plaintext, s); err != nil {
+		return fmt.Errorf("reading %s: %w", profilesFile, err)
 	}
+	s.key = key
+	s.sealed = nil
+	return nil
+}
 
-	tokenPath := fmt.Sprintf("%s/%s", homeDir, tokenFile)
+// setPassphrase saves the profiles encrypted with passphrase, or as plain
+// JSON when passphrase is empty
+func (s *profileStore) setPassphrase(passphrase string) error {
+	if s.locked() {
+		return errProfilesLocked
+	}
+	s.key = nil
+	if passphrase != "" {
+		key, err := newStoreKey(passphrase)
+		if err != nil {
+			return err
+		}
+		s.key = key
+	}
+	return s.save()
+}
 
+func (s *profileStore) migrateTokenFile() error {
+	tokenPath, err := homePath(tokenFile)
+	if err != nil {
+		return err
+	}
 	data, err := os.ReadFile(tokenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		return "", "", 0, err
+		return err
 	}
 
 	var saved savedToken
 	if err := json.Unmarshal(data, &saved); err != nil {
-		return "", "", 0, err
+		return err
 	}
+	s.upsert(profile{AccessToken: saved.AccessToken, Username: saved.Username, UserID: saved.UserID})
+	s.setActive(trackerAniList, saved.UserID)
+	if err := s.save(); err != nil {
+		return err
+	}
+	return os.Remove(tokenPath)
+}
 
-	_, _, err = getUserInfo(saved.AccessToken)
+func (s *profileStore) save() error {
+	// Saving now would replace the encrypted profiles
+	if s.locked() {
+		return errProfilesLocked
+	}
+	path, err := homePath(profilesFile)
 	if err != nil {
-		os.Remove(tokenPath)
-		return "", "", 0, fmt.Errorf("token expired")
+		return err
 	}
+	jsonData, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if s.key != nil {
+		sealed, err := s.key.seal(jsonData)
+		if err != nil {
+			return err
+		}
+		if jsonData, err = json.MarshalIndent(sealed, "", "  "); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, jsonData, 0600)
+}
+
+// active returns the active profile, or nil when nobody is logged in
+func (s *profileStore) active() *profile {
+	if s.Active == 0 {
+		return nil
+	}
+	return s.find(s.ActiveTracker, s.Active)
+}
+
+// setActive makes the profile of userID on tracker the active one
+func (s *profileStore) setActive(tracker string, userID int) {
+	s.Active = userID
+	s.ActiveTracker = ""
+	if profileTracker(tracker) != trackerAniList {
+		s.ActiveTracker = tracker
+	}
+}
 
-	// Token is valid, return the saved data
-	return saved.AccessToken, saved.Username, saved.UserID, nil
+func (s *profileStore) find(tracker string, userID int) *profile {
+	for i := range s.Profiles {
+		if s.Profiles[i].is(tracker, userID) {
+			return &s.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// upsert adds p or refreshes the account details of the profile with the
+// same user on the same tracker, keeping its preferences
+func (s *profileStore) upsert(p profile) *profile {
+	if existing := s.find(p.Tracker, p.UserID); existing != nil {
+		existing.setToken(p.token())
+		existing.Username = p.Username
+		existing.Tracker = p.Tracker
+		return existing
+	}
+	s.Profiles = append(s.Profiles, p)
+	return &s.Profiles[len(s.Profiles)-1]
+}
+
+// logout forgets the token of a profile but keeps it listed
+func (s *profileStore) logout(tracker string, userID int) {
+	if p := s.find(tracker, userID); p != nil {
+		p.setToken(authToken{})
+	}
+	if p := s.active(); p != nil && p.is(tracker, userID) {
+		s.setActive("", 0)
+	}
+}
+
+// loadActiveProfile returns the active profile and its user once the
+// profile's tracker accepts its token. A token that is rejected is forgotten.
+func loadActiveProfile(client *anilist.Client, malClient *mal.Client, store *profileStore) (*profile, *anilist.Viewer, error) {
+	p := store.active()
+	if p == nil || !p.LoggedIn() {
+		return nil, nil, fmt.Errorf("no active profile")
+	}
+
+	// Validate against the tracker, AniList's cached viewer only stands in
+	// when offline
+	tracker := newTracker(p.Tracker, client.WithCachePolicy(anilist.NetworkFirst), malClient)
+	ctx, cancel := aniListContext()
+	defer cancel()
+
+	// A revoked refresh token is forgotten, other failures may pass
+	token, err := freshToken(ctx, tracker, p.token())
+	if err != nil {
+		if tokenRejected(err) {
+			store.logout(p.Tracker, p.UserID)
+			store.save()
+		}
+		return nil, nil, fmt.Errorf("refreshing token: %w", err)
+	}
+	if token.AccessToken != p.AccessToken {
+		p.setToken(token)
+		store.save()
+	}
+
+	viewer, err := getUserInfo(tracker, p.AccessToken)
+	if tokenRejected(err) {
+		store.logout(p.Tracker, p.UserID)
+		store.save()
+		return nil, nil, fmt.Errorf("token expired")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, viewer, nil
 }
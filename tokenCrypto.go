@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt cost used for new passphrases. The parameters are stored with the
+// file, so they can be raised later without breaking old files.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Largest scrypt parameters a profiles file may ask for. The file can be
+// edited by anyone, and N = 1<<20 with r = 16 already needs 2 GiB.
+const (
+	maxScryptN = 1 << 20
+	maxScryptR = 16
+	maxScryptP = 4
+)
+
+var (
+	errWrongPassphrase = errors.New("wrong passphrase")
+	errProfilesLocked  = errors.New("profiles are locked, enter the passphrase first")
+)
+
+// sealedStore is the on-disk form of a passphrase protected profileStore
+type sealedStore struct {
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// storeKey is a key derived from the passphrase together with its parameters
+type storeKey struct {
+	key     []byte
+	salt    []byte
+	n, r, p int
+}
+
+func newStoreKey(passphrase string) (*storeKey, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return deriveStoreKey(passphrase, salt, scryptN, scryptR, scryptP)
+}
+
+func deriveStoreKey(passphrase string, salt []byte, n, r, p int) (*storeKey, error) {
+	if n <= 1 || n > maxScryptN || n&(n-1) != 0 || r < 1 || r > maxScryptR || p < 1 || p > maxScryptP {
+		return nil, fmt.Errorf("unsupported scrypt parameters N=%d r=%d p=%d", n, r, p)
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+	return &storeKey{key: key, salt: salt, n: n, r: r, p: p}, nil
+}
+
+func (k *storeKey) seal(plaintext []byte) (*sealedStore, error) {
+	gcm, err := newGCM(k.key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &sealedStore{
+		KDF:        "scrypt",
+		Salt:       k.salt,
+		N:          k.n,
+		R:          k.r,
+		P:          k.p,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, nil
+}
+
+// open decrypts the store, returning the key so it can be sealed again
+func (s *sealedStore) open(passphrase string) ([]byte, *storeKey, error) {
+	if s.KDF != "scrypt" {
+		return nil, nil, errors.New("unsupported key derivation " + s.KDF)
+	}
+	key, err := deriveStoreKey(passphrase, s.Salt, s.N, s.R, s.P)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := newGCM(key.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(s.Nonce) != gcm.NonceSize() {
+		return nil, nil, errors.New("invalid nonce")
+	}
+	plaintext, err := gcm.Open(nil, s.Nonce, s.Ciphertext, nil)
+	if err != nil {
+		return nil, nil, errWrongPassphrase
+	}
+	return plaintext, key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// isSealed reports whether data is an encrypted profiles file
+func isSealed(data []byte) bool {
+	var probe struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Ciphertext != nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	key, err := deriveStoreKey("hunter2", []byte("0123456789abcdef"), 1<<10, 8, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte(`{"profiles":[]}`)
+	sealed, err := key.seal(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed.Ciphertext, plaintext) {
+		t.Error("ciphertext contains the plaintext")
+	}
+
+	got, opened, err := sealed.open("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("got %q, want %q", got, plaintext)
+	}
+	if !bytes.Equal(opened.key, key.key) || opened.n != key.n || opened.r != key.r || opened.p != key.p {
+		t.Error("opened key differs from the sealing key")
+	}
+
+	if _, _, err := sealed.open("wrong"); !errors.Is(err, errWrongPassphrase) {
+		t.Errorf("wrong passphrase: got %v", err)
+	}
+}
+
+func TestDeriveStoreKeyRejectsParameters(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	tests := []struct {
+		name    string
+		n, r, p int
+	}{
+		{"N too large", 1 << 21, 8, 1},
+		{"N not a power of two", 1000, 8, 1},
+		{"N one", 1, 8, 1},
+		{"N negative", -1024, 8, 1},
+		{"r too large", 1 << 10, 17, 1},
+		{"r zero", 1 << 10, 0, 1},
+		{"p too large", 1 << 10, 8, 5},
+		{"p zero", 1 << 10, 8, 0},
+	}
+	for _, tt := range tests {
+		if _, err := deriveStoreKey("pass", salt, tt.n, tt.r, tt.p); err == nil {
+			t.Errorf("%s: N=%d r=%d p=%d accepted", tt.name, tt.n, tt.r, tt.p)
+		}
+	}
+}
+
+func TestOpenRejectsBadNonce(t *testing.T) {
+	key, err := deriveStoreKey("pass", []byte("0123456789abcdef"), 1<<10, 8, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := key.seal([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed.Nonce = sealed.Nonce[:4]
+	if _, _, err := sealed.open("pass"); err == nil {
+		t.Error("short nonce accepted")
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TorrentSource is a site torrents are searched on
+type TorrentSource interface {
+	// Name identifies the source in settings and tags its torrents
+	Name() string
+	// Search returns the torrents matching query. It stops when ctx is done.
+	Search(ctx context.Context, query string) ([]Torrent, error)
+}
+
+// torrentSources are every known source, SAKUHAKU_TORRENT_SOURCES picks the
+// ones that are searched
+var torrentSources = []TorrentSource{
+	animeToshoSource{},
+	nyaaSource{},
+}
+
+// How many queries of a search a source is sent at once. Nyaa resets
+// connections when it gets too many.
+const maxSourceRequests = 2
+
+// torrentSourceMsg carries the results of one source for every query of a
+// search
+type torrentSourceMsg struct {
+	search   int
+	source   string
+	torrents []Torrent
+	err      error
+}
+
+// getTorrentFeed fetches a source's search feed
+func getTorrentFeed(ctx context.Context, feedURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// searchTorrents searches every enabled source for every query. Each source
+// answers on its own, so results show up as soon as the fastest one is done.
+func (m *model) searchTorrents(queries ...string) tea.Cmd {
+	m.stopTorrentSearch()
+	sources := enabledTorrentSources()
+	if len(sources) == 0 {
+		m.loading = false
+		m.statusMsg = "No torrent sources enabled, check SAKUHAKU_TORRENT_SOURCES"
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.torrentCancel = cancel
+	m.torrentPending = len(sources)
+	cmds := make([]tea.Cmd, len(sources))
+	for i, source := range sources {
+		cmds[i] = searchTorrentSource(ctx, m.torrentSearch, source, queries)
+	}
+	return tea.Batch(cmds...)
+}
+
+// stopTorrentSearch cancels the running search, results still on their way
+// are dropped
+func (m *model) stopTorrentSearch() {
+	if m.torrentCancel != nil {
+		m.torrentCancel()
+		m.torrentCancel = nil
+	}
+	m.torrentSearch++
+	m.torrentPending = 0
+}
+
+func searchTorrentSource(parent context.Context, search int, source TorrentSource, queries []string) tea.Cmd {
+	return func() tea.Msg {
+		timeout := torrentSourceTimeout(source.Name())
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		defer cancel()
+
+		results := make([][]Torrent, len(queries))
+		errs := make([]error, len(queries))
+		slots := make(chan struct{}, maxSourceRequests)
+		var wg sync.WaitGroup
+		for i, query := range queries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case slots <- struct{}{}:
+					defer func() { <-slots }()
+				case <-ctx.Done():
+					errs[i] = ctx.Err()
+					return
+				}
+				results[i], errs[i] = source.Search(ctx, query)
+			}()
+		}
+		wg.Wait()
+
+		msg := torrentSourceMsg{search: search, source: source.Name()}
+		for _, r := range results {
+			msg.torrents = append(msg.torrents, r...)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			msg.err = fmt.Errorf("timed out after %s", timeout)
+			return msg
+		}
+		for _, err := range errs {
+			if err != nil {
+				msg.err = err
+				break
+			}
+		}
+		return msg
+	}
+}
+
+// setTorrentSourceResults adds the torrents of one source to the results.
+// The first source to answer opens the torrent view.
+func (m *model) setTorrentSourceResults(msg torrentSourceMsg) {
+	m.torrentPending--
+	if m.torrentPending == 0 && m.torrentCancel != nil {
+		m.torrentCancel()
+		m.torrentCancel = nil
+	}
+
+	if m.torrentShown != msg.search {
+		m.torrentShown = msg.search
+		m.loading = false
+		if m.mode != ModeTorrents {
+			m.torrentReturnMode = m.mode
+		}
+		m.mode = ModeTorrents
+		m.torrents = nil
+		m.torrentSeen = map[string]bool{}
+		m.torrentCursor = 0
+		m.torrentPage = 0
+		m.selectedTorrents = make(map[int]struct{})
+		m.statusMsg = ""
+		if m.ready {
+			m.viewport.GotoTop()
+		}
+	}
+
+	if msg.err != nil {
+		m.statusMsg = fmt.Sprintf("%s search failed: %v", msg.source, msg.err)
+	}
+	for _, t := range msg.torrents {
+		key := torrentKey(t)
+		if m.torrentSeen[key] {
+			continue
+		}
+		m.torrentSeen[key] = true
+		t.ID = len(m.torrents)
+		m.torrents = append(m.torrents, t)
+	}
+}
+
+// torrentKey identifies a torrent across sources by its info hash, falling
+// back to its name. Magnets carry the hash as hex or, from some sites, as
+// base32, so it is always compared as hex.
+func torrentKey(t Torrent) string {
+	if u, err := url.Parse(t.MagnetURI); err == nil {
+		if xt := u.Query().Get("xt"); strings.HasPrefix(xt, "urn:btih:") {
+			hash := strings.TrimPrefix(xt, "urn:btih:")
+			if len(hash) == 32 {
+				if raw, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+					return hex.EncodeToString(raw)
+				}
+			}
+			return strings.ToLower(hash)
+		}
+	}
+	return t.Source + "\x00" + t.Title
+}
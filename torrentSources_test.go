@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingSource records how many searches run at the same time
+type countingSource struct {
+	running, peak atomic.Int32
+}
+
+func (s *countingSource) Name() string { return "counting" }
+
+func (s *countingSource) Search(ctx context.Context, query string) ([]Torrent, error) {
+	n := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return []Torrent{{Title: query, Source: s.Name()}}, nil
+}
+
+func TestSearchTorrentSourceLimitsRequests(t *testing.T) {
+	source := &countingSource{}
+	queries := []string{"a", "b", "c", "d", "e", "f"}
+	msg := searchTorrentSource(context.Background(), 1, source, queries)().(torrentSourceMsg)
+
+	if msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if len(msg.torrents) != len(queries) {
+		t.Errorf("got %d torrents, want %d", len(msg.torrents), len(queries))
+	}
+	if peak := source.peak.Load(); peak > maxSourceRequests {
+		t.Errorf("%d requests at once, want at most %d", peak, maxSourceRequests)
+	}
+}
+
+func TestTorrentKey(t *testing.T) {
+	const hash = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	tests := []struct {
+		name    string
+		torrent Torrent
+		want    string
+	}{
+		{"hex", Torrent{MagnetURI: "magnet:?xt=urn:btih:" + hash + "&dn=Show"}, hash},
+		{"upper case hex", Torrent{MagnetURI: "magnet:?xt=urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A"}, hash},
+		{"base32", Torrent{MagnetURI: "magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK&tr=udp://tracker"}, hash},
+		{"lower case base32", Torrent{MagnetURI: "magnet:?xt=urn:btih:yex6dqdlxisuvhoj6um3gnnkpqjwpkek"}, hash},
+		{"no magnet", Torrent{Source: "nyaa", Title: "Show - 01"}, "nyaa\x00Show - 01"},
+	}
+	for _, tt := range tests {
+		if got := torrentKey(tt.torrent); got != tt.want {
+			t.Errorf("%s: torrentKey = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -234,8 +234,9 @@ func (c *TorrentClient) DownloadTorrent(torrent string) error {
 // StartServer starts the HTTP streaming server
 func (c *TorrentClient) StartServer() {
 	port := fmt.Sprintf(":%s", c.Port)
-	c.Server = &http.Server{Addr: port}
-	http.HandleFunc("/stream", c.handler)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream", c.handler)
+	c.Server = &http.Server{Addr: port, Handler: mux}
 
 	go func() {
 		if err := c.Server.ListenAndServe(); err != nil {
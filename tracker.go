@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sunnygitgud/sakuhaku/anilist"
+	"github.com/sunnygitgud/sakuhaku/mal"
+)
+
+// Names of the trackers an account can be on. Profiles saved before
+// MyAnimeList support have no tracker and are AniList accounts.
+const (
+	trackerAniList     = "AniList"
+	trackerMyAnimeList = "MyAnimeList"
+)
+
+// Tracker is a site that keeps the user's anime lists. The UI works with
+// AniList's types, other trackers convert to them. Features beyond these,
+// like the following feed or statistics, only exist for AniList accounts.
+type Tracker interface {
+	Name() string
+
+	// AuthCodeURL is the browser login page. verifier is the PKCE code
+	// verifier, trackers without PKCE ignore it.
+	AuthCodeURL(redirectURI, state, verifier string) string
+	// ExchangeCode trades the code of the login redirect for a token
+	ExchangeCode(ctx context.Context, code, redirectURI, verifier string) (*authToken, error)
+	// RefreshToken trades a refresh token for a new token, trackers whose
+	// tokens can't be refreshed return an error
+	RefreshToken(ctx context.Context, refreshToken string) (*authToken, error)
+	// WithToken returns the tracker acting for the account of token
+	WithToken(token string) Tracker
+	// Viewer returns the account of the token
+	Viewer(ctx context.Context) (*anilist.Viewer, error)
+
+	// ListCollection returns the user's lists, one per status
+	ListCollection(ctx context.Context, userID int) ([]anilist.MediaListGroup, error)
+	// Search returns a page of anime, page counts from 1
+	Search(ctx context.Context, filter anilist.MediaFilter, page, perPage int) (*anilist.MediaPage, error)
+	// SaveEntry saves the status, progress and score of a list entry
+	SaveEntry(ctx context.Context, entry UserAnimeEntry) (*UserAnimeEntry, error)
+	// DeleteEntry removes the anime of entry from the user's lists
+	DeleteEntry(ctx context.Context, entry UserAnimeEntry) error
+}
+
+// authToken is what a login gives. Only some trackers hand out refresh tokens
+// and tell when the access token expires.
+type authToken struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time // zero when unknown
+}
+
+// How long before it expires a token is refreshed
+const tokenRefreshWindow = 7 * 24 * time.Hour
+
+// needsRefresh reports whether t can and should be refreshed now
+func (t authToken) needsRefresh(now time.Time) bool {
+	return t.RefreshToken != "" && !t.Expiry.IsZero() && t.Expiry.Sub(now) < tokenRefreshWindow
+}
+
+// freshToken refreshes t when it is about to expire. A failed refresh keeps
+// t while it is still valid, e.g. when the tracker can't be reached.
+func freshToken(ctx context.Context, tracker Tracker, t authToken) (authToken, error) {
+	if !t.needsRefresh(time.Now()) {
+		return t, nil
+	}
+	refreshed, err := tracker.RefreshToken(ctx, t.RefreshToken)
+	if err != nil {
+		if !tokenRejected(err) && time.Now().Before(t.Expiry) {
+			return t, nil
+		}
+		return t, err
+	}
+	return *refreshed, nil
+}
+
+// newTracker returns the tracker called name. aniList is the client anime
+// data is read through, with the cache policy of the caller.
+func newTracker(name string, aniList *anilist.Client, malClient *mal.Client) Tracker {
+	if name == trackerMyAnimeList {
+		return &malTracker{client: malClient, aniList: aniList}
+	}
+	return &aniListTracker{client: aniList}
+}
+
+// listTracker is the tracker of the logged in account, or the local list
+func (m *model) listTracker() Tracker {
+	return m.listTrackerWith(m.aniList)
+}
+
+// listTrackerWith is listTracker reading anime data through c
+func (m *model) listTrackerWith(c *anilist.Client) Tracker {
+	if m.accessToken == "" {
+		return &localTracker{list: m.localList, aniList: c}
+	}
+	return newTracker(m.trackerName, c, m.mal)
+}
+
+// aniListAccount reports whether the logged in account is on AniList, which
+// the social features, statistics and the full entry editor need
+func (m *model) aniListAccount() bool {
+	return m.accessToken != "" && m.trackerName != trackerMyAnimeList
+}
+
+// requireAniList tells the user when what needs an AniList account
+func (m *model) requireAniList(what string) bool {
+	switch {
+	case m.aniListAccount():
+		return true
+	case m.accessToken == "":
+		m.statusMsg = "Login to " + what
+	default:
+		m.statusMsg = fmt.Sprintf("Login with AniList to %s, %s doesn't support it", what, m.trackerName)
+	}
+	return false
+}
+
+// onAniList tells the user when anime has no AniList page to load details,
+// recommendations or a franchise from. Anime of MyAnimeList lists that
+// AniList doesn't know have negative IDs.
+func (m *model) onAniList(anime Anime) bool {
+	if anime.ID > 0 {
+		return true
+	}
+	m.statusMsg = fmt.Sprintf("%s isn't on AniList", m.animeTitle(anime))
+	if m.ready {
+		m.viewport.SetContent(m.renderContent())
+	}
+	return false
+}
+
+// tokenRejected reports whether a tracker refused a saved token
+func tokenRejected(err error) bool {
+	var aniListErr *anilist.Error
+	if errors.As(err, &aniListErr) {
+		return aniListErr.StatusCode == 400 || aniListErr.StatusCode == 401
+	}
+	var malErr *mal.Error
+	return errors.As(err, &malErr) && malErr.StatusCode == 401
+}
+
+// aniListTracker keeps lists on AniList
+type aniListTracker struct {
+	client *anilist.Client
+}
+
+func (t *aniListTracker) Name() string {
+	return trackerAniList
+}
+
+func (t *aniListTracker) AuthCodeURL(redirectURI, state, _ string) string {
+	return fmt.Sprintf("%s?client_id=%s&redirect_uri=%s&response_type=code&state=%s",
+		authURL, url.QueryEscape(clientID), url.QueryEscape(redirectURI), state)
+}
+
+func (t *aniListTracker) ExchangeCode(ctx context.Context, code, redirectURI, _ string) (*authToken, error) {
+	token, err := exchangeCodeForToken(ctx, code, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+	return &authToken{AccessToken: token}, nil
+}
+
+// RefreshToken isn't needed, AniList tokens last a year
+func (t *aniListTracker) RefreshToken(context.Context, string) (*authToken, error) {
+	return nil, fmt.Errorf("AniList tokens can't be refreshed")
+}
+
+func (t *aniListTracker) WithToken(token string) Tracker {
+	return &aniListTracker{client: t.client.WithToken(token)}
+}
+
+func (t *aniListTracker) Viewer(ctx context.Context) (*anilist.Viewer, error) {
+	return t.client.Viewer(ctx)
+}
+
+func (t *aniListTracker) ListCollection(ctx context.Context, userID int) ([]anilist.MediaListGroup, error) {
+	return t.client.MediaListCollection(ctx, userID, "")
+}
+
+func (t *aniListTracker) Search(ctx context.Context, filter anilist.MediaFilter, page, perPage int) (*anilist.MediaPage, error) {
+	return t.client.SearchMedia(ctx, filter, page, perPage)
+}
+
+func (t *aniListTracker) SaveEntry(ctx context.Context, entry UserAnimeEntry) (*UserAnimeEntry, error) {
+	saved, err := t.client.SaveMediaListEntry(ctx, anilist.SaveMediaListEntryInput{
+		MediaID:  entry.Media.ID,
+		Status:   entry.Status,
+		Progress: entry.Progress,
+		Score:    entry.Score,
+	})
+	if err != nil {
+		return nil, err
+	}
+	saved.Media = entry.Media
+	return saved, nil
+}
+
+func (t *aniListTracker) DeleteEntry(ctx context.Context, entry UserAnimeEntry) error {
+	if entry.ID == 0 {
+		return fmt.Errorf("%s isn't on your list", entry.Media.Title.Preferred(""))
+	}
+	return t.client.DeleteMediaListEntry(ctx, entry.ID)
+}
+
+// malTracker keeps lists on MyAnimeList. Its anime are looked up on AniList
+// by their MyAnimeList ID so that every view works with them, anime AniList
+// doesn't know get their MyAnimeList ID negated as ID.
+type malTracker struct {
+	client  *mal.Client
+	aniList *anilist.Client
+}
+
+func (t *malTracker) Name() string {
+	return trackerMyAnimeList
+}
+
+func (t *malTracker) AuthCodeURL(redirectURI, state, verifier string) string {
+	return t.client.AuthCodeURL(redirectURI, state, verifier)
+}
+
+func (t *malTracker) ExchangeCode(ctx context.Context, code, redirectURI, verifier string) (*authToken, error) {
+	token, err := t.client.ExchangeCode(ctx, code, redirectURI, verifier)
+	if err != nil {
+		return nil, err
+	}
+	return authTokenFromMal(token), nil
+}
+
+func (t *malTracker) RefreshToken(ctx context.Context, refreshToken string) (*authToken, error) {
+	token, err := t.client.Refresh(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return authTokenFromMal(token), nil
+}
+
+// authTokenFromMal converts a MyAnimeList token, which expires after about a
+// month
+func authTokenFromMal(token *mal.Token) *authToken {
+	t := &authToken{AccessToken: token.AccessToken, RefreshToken: token.RefreshToken}
+	if token.ExpiresIn > 0 {
+		t.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return t
+}
+
+func (t *malTracker) WithToken(token string) Tracker {
+	return &malTracker{client: t.client.WithToken(token), aniList: t.aniList}
+}
+
+// Viewer describes the account like AniList would. MyAnimeList scores are
+// whole numbers up to 10.
+func (t *malTracker) Viewer(ctx context.Context) (*anilist.Viewer, error) {
+	user, err := t.client.Me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	viewer := &anilist.Viewer{ID: user.ID, Name: user.Name}
+	viewer.MediaListOptions.ScoreFormat = string(scorePoint10)
+	return viewer, nil
+}
+
+// ListCollection loads the whole list from MyAnimeList, which has no cache.
+// Cache only requests are answered with ErrNotCached.
+func (t *malTracker) ListCollection(ctx context.Context, _ int) ([]anilist.MediaListGroup, error) {
+	if t.aniList.Policy() == anilist.CacheOnly {
+		return nil, anilist.ErrNotCached
+	}
+	entries, err := t.client.AnimeList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]mal.Anime, len(entries))
+	for i, e := range entries {
+		nodes[i] = e.Anime
+	}
+	media, err := t.media(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	byStatus := map[string][]UserAnimeEntry{}
+	for i, e := range entries {
+		entry := entryFromMal(e.ListStatus, media[i])
+		byStatus[entry.Status] = append(byStatus[entry.Status], entry)
+	}
+	var groups []anilist.MediaListGroup
+	for _, tab := range statusTabs {
+		if list := byStatus[tab.status]; len(list) > 0 {
+			groups = append(groups, anilist.MediaListGroup{Name: tab.title(), Status: tab.status, Entries: list})
+		}
+	}
+	return groups, nil
+}
+
+// Search looks up titles on MyAnimeList, which has no other filters
+func (t *malTracker) Search(ctx context.Context, filter anilist.MediaFilter, page, perPage int) (*anilist.MediaPage, error) {
+	rest := filter
+	rest.Search = ""
+	if !rest.IsZero() {
+		return nil, fmt.Errorf("MyAnimeList can only search by title")
+	}
+	if len([]rune(strings.TrimSpace(filter.Search))) < 3 {
+		return nil, fmt.Errorf("MyAnimeList needs at least 3 letters to search")
+	}
+
+	nodes, hasNext, err := t.client.SearchAnime(ctx, filter.Search, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, err
+	}
+	media, err := t.media(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &anilist.MediaPage{Media: media}
+	result.PageInfo.CurrentPage = page
+	result.PageInfo.HasNextPage = hasNext
+	result.PageInfo.LastPage = page
+	if hasNext {
+		result.PageInfo.LastPage++
+	}
+	return result, nil
+}
+
+func (t *malTracker) SaveEntry(ctx context.Context, entry UserAnimeEntry) (*UserAnimeEntry, error) {
+	id := entry.Media.IDMal
+	if id == 0 {
+		return nil, fmt.Errorf("%s isn't on MyAnimeList", entry.Media.Title.Preferred(""))
+	}
+	update := mal.ListStatusUpdate{
+		Status:             malListStatus(entry.Status),
+		Score:              int(math.Round(entry.Score)),
+		NumWatchedEpisodes: entry.Progress,
+		IsRewatching:       entry.Status == "REPEATING",
+	}
+	status, err := t.client.UpdateListStatus(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+	saved := entryFromMal(*status, entry.Media)
+	return &saved, nil
+}
+
+func (t *malTracker) DeleteEntry(ctx context.Context, entry UserAnimeEntry) error {
+	if entry.Media.IDMal == 0 {
+		return fmt.Errorf("%s isn't on MyAnimeList", entry.Media.Title.Preferred(""))
+	}
+	return t.client.DeleteListStatus(ctx, entry.Media.IDMal)
+}
+
+// media converts MyAnimeList anime, keeping their order and the user's list
+// status MyAnimeList sent along
+func (t *malTracker) media(ctx context.Context, nodes []mal.Anime) ([]Anime, error) {
+	ids := make([]int, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	found, err := t.aniList.MediaByMalIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byMalID := map[int]Anime{}
+	for _, a := range found {
+		byMalID[a.IDMal] = a
+	}
+
+	media := make([]Anime, len(nodes))
+	for i, n := range nodes {
+		a, ok := byMalID[n.ID]
+		if !ok {
+			a = animeFromMal(n)
+		}
+		a.MediaListEntry = nil
+		if s := n.ListStatus; s != nil {
+			e := entryFromMal(*s, a)
+			a.MediaListEntry = &MediaListEntry{ID: e.ID, Status: e.Status, Progress: e.Progress, Score: e.Score}
+		}
+		media[i] = a
+	}
+	return media, nil
+}
+
+// animeFromMal builds an anime AniList doesn't know from MyAnimeList's data
+func animeFromMal(n mal.Anime) Anime {
+	a := Anime{
+		ID:          -n.ID,
+		IDMal:       n.ID,
+		Title:       Title{Romaji: n.Title, English: n.AlternativeTitles.En, Native: n.AlternativeTitles.Ja},
+		Synonyms:    n.AlternativeTitles.Synonyms,
+		Type:        "ANIME",
+		Format:      strings.ToUpper(n.MediaType),
+		Description: n.Synopsis,
+		SiteURL:     fmt.Sprintf("https://myanimelist.net/anime/%d", n.ID),
+	}
+	switch n.Status {
+	case "finished_airing":
+		a.Status = "FINISHED"
+	case "currently_airing":
+		a.Status = "RELEASING"
+	case "not_yet_aired":
+		a.Status = "NOT_YET_RELEASED"
+	}
+	if n.NumEpisodes > 0 {
+		eps := n.NumEpisodes
+		a.Episodes = &eps
+	}
+	if n.Mean > 0 {
+		score := int(math.Round(n.Mean * 10))
+		a.Score = &score
+	}
+	if s := n.StartSeason; s != nil {
+		year := s.Year
+		a.Season = strings.ToUpper(s.Season)
+		a.SeasonYear = &year
+	}
+	a.CoverImage.Large = n.MainPicture.Large
+	if a.CoverImage.Large == "" {
+		a.CoverImage.Large = n.MainPicture.Medium
+	}
+	return a
+}
+
+// entryFromMal converts a MyAnimeList list status. MyAnimeList has no entry
+// IDs, the anime's ID stands in.
+func entryFromMal(s mal.ListStatus, anime Anime) UserAnimeEntry {
+	entry := UserAnimeEntry{
+		ID:       anime.IDMal,
+		Status:   aniListListStatus(s),
+		Progress: s.NumEpisodesWatched,
+		Score:    float64(s.Score),
+		Media:    anime,
+		Repeat:   s.NumTimesRewatched,
+		Notes:    s.Comments,
+	}
+	if !s.UpdatedAt.IsZero() {
+		entry.UpdatedAt = s.UpdatedAt.Unix()
+	}
+	entry.StartedAt, _ = parseFuzzyDate(s.StartDate)
+	entry.CompletedAt, _ = parseFuzzyDate(s.FinishDate)
+	return entry
+}
+
+func aniListListStatus(s mal.ListStatus) string {
+	if s.IsRewatching {
+		return "REPEATING"
+	}
+	switch s.Status {
+	case mal.StatusWatching:
+		return "CURRENT"
+	case mal.StatusCompleted:
+		return "COMPLETED"
+	case mal.StatusOnHold:
+		return "PAUSED"
+	case mal.StatusDropped:
+		return "DROPPED"
+	}
+	return "PLANNING"
+}
+
+// malListStatus maps an AniList status, rewatching is a flag on completed
+// entries on MyAnimeList
+func malListStatus(status string) string {
+	switch status {
+	case "CURRENT":
+		return mal.StatusWatching
+	case "COMPLETED", "REPEATING":
+		return mal.StatusCompleted
+	case "PAUSED":
+		return mal.StatusOnHold
+	case "DROPPED":
+		return mal.StatusDropped
+	}
+	return mal.StatusPlanToWatch
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sunnygitgud/sakuhaku/mal"
+)
+
+func TestMalStatusMapping(t *testing.T) {
+	tests := []struct {
+		mal        mal.ListStatus
+		aniList    string
+		backToMal  string
+		rewatching bool
+	}{
+		{mal.ListStatus{Status: mal.StatusWatching}, "CURRENT", mal.StatusWatching, false},
+		{mal.ListStatus{Status: mal.StatusCompleted}, "COMPLETED", mal.StatusCompleted, false},
+		{mal.ListStatus{Status: mal.StatusCompleted, IsRewatching: true}, "REPEATING", mal.StatusCompleted, true},
+		{mal.ListStatus{Status: mal.StatusOnHold}, "PAUSED", mal.StatusOnHold, false},
+		{mal.ListStatus{Status: mal.StatusDropped}, "DROPPED", mal.StatusDropped, false},
+		{mal.ListStatus{Status: mal.StatusPlanToWatch}, "PLANNING", mal.StatusPlanToWatch, false},
+	}
+	for _, tt := range tests {
+		got := aniListListStatus(tt.mal)
+		if got != tt.aniList {
+			t.Errorf("aniListListStatus(%+v) = %s, want %s", tt.mal, got, tt.aniList)
+		}
+		if back := malListStatus(got); back != tt.backToMal {
+			t.Errorf("malListStatus(%s) = %s, want %s", got, back, tt.backToMal)
+		}
+	}
+}
+
+func TestEntryFromMal(t *testing.T) {
+	anime := Anime{ID: 5114, IDMal: 5114}
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := entryFromMal(mal.ListStatus{
+		Status:             mal.StatusCompleted,
+		Score:              9,
+		NumEpisodesWatched: 64,
+		NumTimesRewatched:  1,
+		StartDate:          "2023-10",
+		FinishDate:         "2024-02-29",
+		Comments:           "great",
+		UpdatedAt:          updated,
+	}, anime)
+
+	if entry.ID != 5114 || entry.Status != "COMPLETED" || entry.Score != 9 || entry.Progress != 64 {
+		t.Errorf("got %+v", entry)
+	}
+	if entry.Repeat != 1 || entry.Notes != "great" || entry.UpdatedAt != updated.Unix() {
+		t.Errorf("got %+v", entry)
+	}
+	if entry.StartedAt.String() != "Oct 2023" || entry.CompletedAt.Day == nil || *entry.CompletedAt.Day != 29 {
+		t.Errorf("dates %s %s", entry.StartedAt, entry.CompletedAt)
+	}
+}
+
+func TestMalTrackerSaveEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/anime/21/my_list_status" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		r.ParseForm()
+		if r.PostForm.Get("score") != "8" || r.PostForm.Get("status") != mal.StatusCompleted || r.PostForm.Get("is_rewatching") != "true" {
+			t.Errorf("form = %v", r.PostForm)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": "completed", "score": 8, "num_episodes_watched": 3, "is_rewatching": true})
+	}))
+	defer server.Close()
+
+	client := mal.NewClient("id").WithToken("token")
+	client.Endpoint = server.URL
+	tracker := &malTracker{client: client}
+
+	saved, err := tracker.SaveEntry(context.Background(), UserAnimeEntry{
+		Status:   "REPEATING",
+		Progress: 3,
+		Score:    7.6,
+		Media:    Anime{ID: 21, IDMal: 21},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != "REPEATING" || saved.Score != 8 || saved.Progress != 3 {
+		t.Errorf("got %+v", saved)
+	}
+
+	if _, err := tracker.SaveEntry(context.Background(), UserAnimeEntry{Media: Anime{ID: 1}}); err == nil {
+		t.Error("saved an anime without a MyAnimeList ID")
+	}
+}
+
+func TestAnimeFromMalHasNegativeID(t *testing.T) {
+	a := animeFromMal(mal.Anime{ID: 99, Title: "Unknown", Status: "currently_airing", NumEpisodes: 12})
+	if a.ID != -99 || a.IDMal != 99 || a.Status != "RELEASING" || a.Episodes == nil || *a.Episodes != 12 {
+		t.Errorf("got %+v", a)
+	}
+	m := &model{}
+	if m.onAniList(a) {
+		t.Error("anime AniList doesn't know passed the AniList guard")
+	}
+}
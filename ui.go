@@ -1,13 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/sunnygitgud/sakuhaku/anilist"
 	tc "github.com/sunnygitgud/sakuhaku/torrentclient"
 )
 
@@ -30,25 +33,55 @@ func initialModel() *model {
 		fmt.Printf("Failed to initialize torrent client: %v", err)
 	}
 
+	aniList := anilist.NewClient()
+	cache, err := anilist.OpenCache(cachePath())
+	if err != nil {
+		debugLog(fmt.Sprintf("AniList cache disabled: %v", err))
+	} else {
+		aniList = aniList.WithCache(cache)
+	}
+
+	localList, err := loadLocalList(localListPath())
+	if err != nil {
+		debugLog(err.Error())
+	}
+
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = spinnerStyle
 	m := &model{
 		mode:             ModeLogin,
 		selectedTorrents: make(map[int]struct{}),
-		loginMsg:         "Press 'l' to login with AniList or 's' to browse without login",
+		loginMsg:         "Press 'l' to login with AniList, 'm' with MyAnimeList, 'p' to paste an AniList token on headless machines or 's' to browse without login",
 		torrentClient:    client,
+		aniList:          aniList,
+		aniListCache:     cache,
+		mal:              newMalClient(),
+		localList:        localList,
 		spinner:          s,
 		loading:          false,
 	}
 
-	// Try to load saved token
-	if token, username, userID, err := loadSavedToken(); err == nil {
-		m.accessToken = token
-		m.username = username
-		m.userID = userID
+	profiles, err := loadProfiles()
+	if err != nil {
+		debugLog(fmt.Sprintf("Failed to load profiles: %v", err))
+	}
+	m.profiles = profiles
+
+	if profiles.locked() {
+		m.loginMsg = "Your profiles are encrypted, enter the passphrase to unlock them or Esc to skip"
+		m.askPassphrase(promptUnlock)
+		return m
+	}
+
+	// Resume the active profile
+	if p, viewer, err := loadActiveProfile(aniList, m.mal, profiles); err == nil {
+		m.startSession(p.Tracker, p.AccessToken, viewer)
 		m.mode = ModeUserList
-		m.loginMsg = fmt.Sprintf("Welcome back, %s!", username)
+		m.loginMsg = fmt.Sprintf("Welcome back, %s!", m.username)
+		if !profiles.encrypted() {
+			m.statusMsg = fmt.Sprintf("⚠ Your %s token is saved unencrypted, press P then e to set a passphrase", m.listTracker().Name())
+		}
 		m.loading = true
 		m.loadingMsg = "Loading your anime list..."
 	}
@@ -59,7 +92,7 @@ func initialModel() *model {
 func (m *model) Init() tea.Cmd {
 	// If we have a token, fetch user list immediately
 	if m.accessToken != "" && m.userID != 0 {
-		return tea.Batch(m.spinner.Tick, fetchUserAnimeList(m.accessToken, m.userID, "CURRENT"))
+		return tea.Batch(m.spinner.Tick, m.fetchCurrentList(), m.watchNotifications())
 	}
 	return m.spinner.Tick
 }
@@ -85,6 +118,13 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.activeTorrent = msg.Torrent
 
 		vidfile := tc.GetLargestVideoFile(msg.Torrent)
+		m.playing = nil
+		if m.selectedAnime != nil {
+			m.playing = &playbackSession{anime: *m.selectedAnime, file: msg.Torrent.Name()}
+			if vidfile != nil {
+				m.playing.file = vidfile.DisplayPath()
+			}
+		}
 		if vidfile != nil {
 			m.streamURL = m.torrentClient.ServeTorrentEpisode(msg.Torrent, vidfile.DisplayPath())
 			return m, openVideoPlayer(m.streamURL)
@@ -108,17 +148,29 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case videoPlayerOpenedMsg:
+		if msg.ipcPath != "" && m.playing != nil {
+			return m, watchMpvPlayback(msg.ipcPath, *m.playing, m.watchThreshold())
+		}
+		return m, nil
+
+	case episodeWatchedMsg:
+		return m, m.syncWatchedEpisode(msg.session)
+
 	case authSuccessMsg:
-		m.accessToken = msg.token
-		m.username = msg.username
-		m.userID = msg.userID
+		m.endLogin()
+		m.endSession()
+		m.startSession(msg.tracker, msg.token.AccessToken, msg.viewer)
 		m.mode = ModeUserList
 		m.loading = true
 		m.loadingMsg = "Loading your anime list..."
 		m.loginMsg = fmt.Sprintf("Logged in as %s! Loading your anime list...", m.username)
 
-		if err := saveToken(msg.token, msg.username, msg.userID); err != nil {
-			m.loginMsg = fmt.Sprintf("Logged in but failed to save token: %v", err)
+		p := m.profiles.upsert(profile{Username: m.username, UserID: m.userID, Tracker: msg.tracker})
+		p.setToken(msg.token)
+		m.profiles.setActive(msg.tracker, m.userID)
+		if err := m.profiles.save(); err != nil {
+			m.statusMsg = fmt.Sprintf("Logged in but failed to save token: %v", err)
 		}
 
 		if !m.ready {
@@ -126,26 +178,284 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.ready = true
 		}
 
-		return m, tea.Batch(m.spinner.Tick, fetchUserAnimeList(m.accessToken, m.userID, "CURRENT"))
+		return m, tea.Batch(m.spinner.Tick, m.fetchCurrentList(), m.watchNotifications())
 
 	case authErrorMsg:
-		m.loginMsg = fmt.Sprintf("Login failed: %v\nPress 'l' to retry or 's' to browse without login", msg.err)
+		m.loading = false
+		m.endLogin()
+		if errors.Is(msg.err, errLoginCancelled) {
+			m.loginMsg = "Login cancelled. Press 'l' or 'p' to login or 's' to browse without login"
+			return m, nil
+		}
+		m.loginMsg = fmt.Sprintf("Login failed: %v\nPress 'l' or 'p' to retry or 's' to browse without login", msg.err)
 		return m, nil
 
-	case userListMsg:
+	case aniListErrorMsg:
+		// Nothing cached yet, the network request that follows will answer
+		if errors.Is(msg.err, anilist.ErrNotCached) {
+			return m, nil
+		}
 		m.loading = false
-		m.mode = ModeUserList
-		m.userEntries = []UserAnimeEntry(msg)
+		m.listLoadingMore = false
+		m.statusMsg = msg.err.Error()
+		if m.mode == ModeLogin {
+			m.loginMsg = fmt.Sprintf("%v\nPress 'l' or 'p' to retry login or 's' to browse without login", msg.err)
+		}
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+		}
+		return m, nil
+
+	case userListMsg:
+		// Drop pages of a list the user has already switched away from
+		if msg.listType != m.currentListType {
+			return m, nil
+		}
+
+		if !msg.refresh {
+			m.loading = false
+			m.listLoadingMore = false
+			m.mode = ModeUserList
+		}
+		m.listPage = msg.page
+		m.overlayLocal(msg.entries...)
+		m.listHasNextPage = msg.hasNextPage
+
+		if msg.page > 1 {
+			m.userEntries = append(m.userEntries, msg.entries...)
+			m.statusMsg = ""
+		} else {
+			m.userEntries = msg.entries
+		}
+		if m.userEntryCursor >= len(m.userEntries) {
+			m.userEntryCursor = max(0, len(m.userEntries)-1)
+		}
 
 		if !m.ready {
 			m.viewport = viewport.New(80, 24)
 			m.ready = true
 		}
+		if m.mode != ModeUserList {
+			return m, nil
+		}
 
 		content := m.renderContent()
 		m.viewport.SetContent(content)
-		m.viewport.GotoTop()
+		// A background refresh of the first page keeps the user's position
+		if msg.page <= 1 && m.userEntryCursor == 0 {
+			m.viewport.GotoTop()
+		}
+
+		return m, nil
+
+	case collectionMsg:
+		if !msg.refresh {
+			m.loading = false
+		}
+		m.setCollection(msg.groups)
+
+		if !m.ready {
+			m.viewport = viewport.New(80, 24)
+			m.ready = true
+		}
+		if msg.refresh && m.mode != ModeUserList {
+			return m, nil
+		}
+
+		if m.currentListType.Personal() {
+			return m, m.showTab()
+		}
+		m.viewport.SetContent(m.renderContent())
+		return m, nil
+
+	case listEntrySavedMsg:
+		// The entry was edited again since, rolling back or showing this
+		// answer would undo that edit
+		if msg.seq != m.entrySaves[msg.prev.Media.ID] {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.storeEntry(msg.prev)
+			m.storeCustomLists(msg.prev)
+			m.statusMsg = fmt.Sprintf("%s update failed: %v", m.listTracker().Name(), msg.err)
+		} else {
+			m.storeEntry(msg.entry)
+			m.statusMsg = "Saved to " + m.listTracker().Name()
+		}
+		if m.ready {
+			m.refreshTab()
+		}
+		return m, nil
+
+	case listEntryDeletedMsg:
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Deleting failed: %v", msg.err)
+		} else {
+			m.storeEntry(UserAnimeEntry{Media: msg.entry.Media})
+			m.statusMsg = fmt.Sprintf("Deleted %s from your list", m.animeTitle(msg.entry.Media))
+		}
+		if m.ready {
+			m.refreshTab()
+		}
+		return m, nil
+
+	case entryDetailsMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Failed to load list entry: %v", msg.err)
+			return m, nil
+		}
+		m.entryEditor = newEntryEditor(msg, m.scoreFormat)
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+		}
+		return m, nil
+
+	case animeDetailMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Failed to load details: %v", msg.err)
+			return m, nil
+		}
+		m.detail = msg.detail
+		m.mode = ModeDetail
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+		}
+		return m, nil
+
+	case recommendationsMsg:
+		m.pushRecommendations(msg)
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+		}
+		return m, nil
+
+	case activitiesMsg:
+		cmd := m.setActivities(msg)
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			// A background refresh of the first page keeps the user's position
+			if msg.page <= 1 && m.activityCursor == 0 {
+				m.viewport.GotoTop()
+			}
+		}
+		return m, cmd
+
+	case followedListsMsg:
+		m.setFollowedLists(msg)
+		if m.ready && m.mode == ModeSocial {
+			m.viewport.SetContent(m.renderContent())
+		}
+		return m, nil
+
+	case unreadCountMsg:
+		return m, m.setUnreadCount(msg)
+
+	case notificationPollMsg:
+		if msg.session != m.session {
+			return m, nil
+		}
+		return m, fetchUnreadCount(m.aniList, msg.session)
+
+	case notificationsMsg:
+		m.setNotifications(msg)
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			if msg.page <= 1 {
+				m.viewport.GotoTop()
+			}
+		}
+		return m, nil
+
+	case listExportedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Export failed: %v", msg.err)
+			return m, nil
+		}
+		m.statusMsg = fmt.Sprintf("Exported %d anime to %s and .json", msg.count, msg.path)
+		if msg.skipped > 0 {
+			m.statusMsg += fmt.Sprintf(", %d without a MyAnimeList ID are only in the JSON", msg.skipped)
+		}
+		return m, nil
+
+	case importPlanMsg:
+		m.setImportPlan(msg)
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+		}
+		return m, nil
+
+	case importBatchMsg:
+		cmd := m.setImportBatch(msg)
+		if m.ready {
+			m.refreshTab()
+		}
+		return m, cmd
+
+	case importNextBatchMsg:
+		if m.listImport == nil || msg.session != m.session {
+			return m, nil
+		}
+		return m, m.nextImportBatch()
+
+	case statisticsMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Failed to load statistics: %v", msg.err)
+			return m, nil
+		}
+		m.stats = msg.stats
+		m.mode = ModeStats
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+		}
+		return m, nil
 
+	case franchiseMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Failed to load franchise: %v", msg.err)
+			return m, nil
+		}
+		m.franchise = newFranchiseView(msg.mediaID, msg.nodes)
+		m.mode = ModeFranchise
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			m.ensureCursorVisible(1)
+		}
+		return m, nil
+
+	case airingScheduleMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Failed to load airing schedule: %v", msg.err)
+			return m, nil
+		}
+		m.mode = ModeSchedule
+		m.schedules = msg.schedules
+		m.scheduleSeasonWide = msg.seasonWide
+		m.scheduleCursor = 0
+		// Start at the next episode to air
+		now := time.Now().Unix()
+		for i, s := range m.schedules {
+			if s.AiringAt > now {
+				m.scheduleCursor = i
+				break
+			}
+		}
+		if m.ready {
+			m.viewport.SetContent(m.renderContent())
+			m.viewport.GotoTop()
+			m.ensureCursorVisible(1)
+		}
 		return m, nil
 
 	case animeSearchResultMsg:
@@ -160,16 +470,13 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case torrentSearchResultMsg:
-		m.loading = false
-		m.mode = ModeTorrents
-		m.torrents = []Torrent(msg)
-		m.torrentCursor = 0
-		m.torrentPage = 0
-		m.selectedTorrents = make(map[int]struct{})
+	case torrentSourceMsg:
+		if msg.search != m.torrentSearch {
+			return m, nil
+		}
+		m.setTorrentSourceResults(msg)
 		if m.ready {
 			m.viewport.SetContent(m.renderContent())
-			m.viewport.GotoTop()
 		}
 		return m, nil
 
@@ -208,73 +515,194 @@ func (m *model) View() string {
 
 // UI Handlers
 func (m *model) handleWindowResize(msg tea.WindowSizeMsg) {
-	headerHeight := lipgloss.Height(m.headerView())
-	footerHeight := lipgloss.Height(m.footerView())
-	verticalMargin := headerHeight + footerHeight
-
+	m.height = msg.Height
 	if !m.ready {
-		m.viewport = viewport.New(msg.Width, msg.Height-verticalMargin)
-		m.viewport.YPosition = headerHeight
-		m.viewport.SetContent(m.renderContent())
+		m.viewport = viewport.New(msg.Width, 0)
 		m.ready = true
-	} else {
-		m.viewport.Width = msg.Width
-		m.viewport.Height = msg.Height - verticalMargin
-
-		// Re-render content with new dimensions (images will auto-resize)
-		m.viewport.SetContent(m.renderContent())
 	}
+	m.viewport.Width = msg.Width
+	m.fitViewport()
+
+	// Re-render content with new dimensions (images will auto-resize)
+	m.viewport.SetContent(m.renderContent())
 }
 
 func (m *model) headerView() string {
 	var title string
 	if m.searchMode {
 		title = titleStyle.Render(fmt.Sprintf("Search Anime: %s_", m.searchInput))
+	} else if m.scoreMode {
+		title = titleStyle.Render(fmt.Sprintf("Score (%s): %s_", m.scoreFormat.hint(), m.scoreInput))
+	} else if m.entryEditor != nil {
+		title = titleStyle.Render("✏️ Edit List Entry")
+	} else if m.filterForm != nil {
+		title = titleStyle.Render("🎛 Search Filters")
+	} else if m.importForm != nil {
+		title = titleStyle.Render("📥 Import List")
 	} else {
 		switch m.mode {
 		case ModeUserList:
-			title = titleStyle.Render(fmt.Sprintf("👤 %s's List", m.username))
+			if m.username == "" {
+				title = titleStyle.Render("👤 Local List")
+			} else {
+				title = titleStyle.Render(fmt.Sprintf("👤 %s's List", m.username))
+			}
 		case ModeAnimeSearch:
 			title = titleStyle.Render("🔍 Browse Anime")
 		case ModeTorrents:
 			title = titleStyle.Render("📦 Torrent Results")
+		case ModeDetail:
+			title = titleStyle.Render("📖 Anime Details")
+		case ModeRecommendations:
+			title = titleStyle.Render("💡 Recommendations")
+		case ModeFranchise:
+			title = titleStyle.Render("🌳 Franchise")
+		case ModeSocial:
+			title = titleStyle.Render("👥 Following")
+		case ModeStats:
+			title = titleStyle.Render(fmt.Sprintf("📊 %s's Statistics", m.username))
+		case ModeNotifications:
+			title = titleStyle.Render("🔔 Notifications")
+		case ModeImport:
+			title = titleStyle.Render("📥 Import List")
+		case ModeSchedule:
+			if m.scheduleSeasonWide {
+				title = titleStyle.Render("📅 Airing This Season")
+			} else {
+				title = titleStyle.Render("📅 Airing From Your List")
+			}
 		}
 	}
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)))
-	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
+	badge := m.unreadBadge()
+	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)-lipgloss.Width(badge)))
+	return lipgloss.JoinHorizontal(lipgloss.Center, title, badge, line)
 }
 
 func (m *model) footerView() string {
 	var pageInfo string
 	if m.searchMode {
 		pageInfo = "Enter to search | Esc to cancel"
+	} else if m.scoreMode {
+		pageInfo = "Enter to save | Esc to cancel"
+	} else if m.entryEditor != nil {
+		pageInfo = "Tab/↑↓: field | ←/→: choose | Enter: save | ctrl+d: delete | Esc: cancel"
+	} else if m.filterForm != nil {
+		pageInfo = "Tab/↑↓: field | ←/→: choose | Enter: search | ctrl+r: reset | Esc: cancel"
+	} else if m.importForm != nil {
+		pageInfo = "Enter: preview the import | Esc: cancel"
 	} else {
+		var info string
 		switch m.mode {
 		case ModeUserList:
-			pageInfo = fmt.Sprintf("%d anime | Tab: switch list |s: search | r: refresh | L: logout | Enter: torrents | q: quit", len(m.userEntries))
+			info = fmt.Sprintf("%d", len(m.userEntries))
+			if m.listHasNextPage {
+				info += "+"
+			}
+			info += " anime"
 		case ModeAnimeSearch:
-			pageInfo = fmt.Sprintf("Page %d/%d | s: search | n/p: page | Enter: torrents | Esc: back | q: quit",
-				m.animePage+1, m.animeTotalPages)
+			info = fmt.Sprintf("Page %d/%d", m.animePage+1, m.animeTotalPages)
+		case ModeNotifications:
+			info = fmt.Sprintf("%d notifications", len(m.notifications))
+		case ModeImport:
+			info = fmt.Sprintf("%d changes", len(m.listImport.changes))
+		case ModeSocial:
+			info = fmt.Sprintf("%d updates", len(m.activities))
+		case ModeFranchise:
+			info = fmt.Sprintf("%d anime", len(m.franchise.nodes))
+		case ModeRecommendations:
+			info = fmt.Sprintf("Level %d", len(m.recStack))
+		case ModeSchedule:
+			info = fmt.Sprintf("%d episodes", len(m.schedules))
 		case ModeTorrents:
 			perPage := 20
 			startIdx := m.torrentPage*perPage + 1
 			endIdx := min(startIdx+len(m.visibleTorrents(perPage))-1, len(m.torrents))
-			pageInfo = fmt.Sprintf("Page %d/%d | %d-%d of %d | Space/Enter: select | Esc: back | q: quit",
+			info = fmt.Sprintf("Page %d/%d | %d-%d of %d",
 				m.torrentPage+1, m.totalTorrentPages(perPage), startIdx, endIdx, len(m.torrents))
+			if m.torrentPending > 0 {
+				info = fmt.Sprintf("Sources still searching: %d | %s", m.torrentPending, info)
+			}
 			// Add streaming status if active
 			if m.activeTorrent != nil && m.downloadProgress < 100 {
-				pageInfo += fmt.Sprintf(" | Downloading: %.1f%%", m.downloadProgress)
+				info += fmt.Sprintf(" | Downloading: %.1f%%", m.downloadProgress)
 			} else if m.streamURL != "" {
-				pageInfo += " | Streaming active"
+				info += " | Streaming active"
 			}
 		}
+
+		keys, more := m.footerKeys()
+		if m.showKeys {
+			keys = append(keys, more...)
+		} else if len(more) > 0 {
+			keys = append(keys, "?: more keys")
+		}
+		if info != "" {
+			keys = append([]string{info}, keys...)
+		}
+		pageInfo = strings.Join(keys, " | ")
+		if m.statusMsg != "" {
+			pageInfo = m.statusMsg + " | " + pageInfo
+		}
+		if m.aniList.Offline() {
+			pageInfo = "⚠ Offline, showing cached data | " + pageInfo
+		}
 	}
 
 	info := infoStyle.Render(pageInfo)
+	if lipgloss.Width(info) > m.viewport.Width {
+		// Wrap rather than let the terminal do it, so fitViewport can measure it
+		info = infoStyle.Width(max(1, m.viewport.Width-1)).Render(pageInfo)
+	}
 	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(info)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
 }
 
+// footerKeys returns the keys of the current view for the footer. The first
+// ones are always shown, the others only after pressing '?'.
+func (m *model) footerKeys() (keys, more []string) {
+	switch m.mode {
+	case ModeUserList:
+		return []string{"Tab: switch list", "s: search", "+/-: progress", "e: edit", "Enter: torrents", "q: quit"},
+			[]string{"Shift+Tab: previous list", "f: filters", "r: refresh", "c: status", "x: score", "ctrl+d: delete", "i: details", "R: similar", "F: franchise", "a: airing", "S: following", "D: stats", "N: inbox", "E: export", "I: import", "P: profiles", "L: logout"}
+	case ModeAnimeSearch:
+		return []string{"s: search", "n/p: page", "e: edit", "Enter: torrents", "Esc: back"},
+			[]string{"f: filters", "+/-: progress", "c: status", "x: score", "ctrl+d: delete", "i: details", "R: similar", "F: franchise", "a: airing", "S: following", "D: stats", "N: inbox", "q: quit"}
+	case ModeDetail:
+		return []string{"↑/↓: scroll", "Enter: torrents", "Esc: back"},
+			[]string{"R: recommendations", "F: franchise", "q: quit"}
+	case ModeNotifications:
+		return []string{"Enter: torrents for the episode", "Esc: back"},
+			[]string{"r: refresh", "i: details", "e: edit", "R: similar", "F: franchise", "q: quit"}
+	case ModeStats:
+		return []string{"↑/↓: scroll", "Esc: back", "q: quit"}, nil
+	case ModeImport:
+		return []string{"Enter: apply", "Esc: back", "q: quit"}, nil
+	case ModeSocial:
+		return []string{"w: plan to watch", "Enter: torrents", "Esc: back"},
+			[]string{"r: refresh", "i: details", "e: edit", "R: similar", "F: franchise", "q: quit"}
+	case ModeFranchise:
+		return []string{"o: tree/watch order", "Enter: torrents", "Esc: back"},
+			[]string{"w: plan to watch", "i: details", "e: edit", "R: similar", "q: quit"}
+	case ModeRecommendations:
+		return []string{"→/R: recommendations for this", "←/Esc: back", "Enter: torrents"},
+			[]string{"w: plan to watch", "i: details", "e: edit", "F: franchise", "q: quit"}
+	case ModeSchedule:
+		return []string{"t: my list/season", "Enter: torrents for episode", "Esc: back", "q: quit"}, nil
+	case ModeTorrents:
+		return []string{"Space/Enter: select", "Esc: back", "q: quit"}, nil
+	}
+	return nil, nil
+}
+
+// fitViewport gives the viewport the lines the header and footer leave. The
+// footer wraps on narrow terminals, so it is measured every time.
+func (m *model) fitViewport() {
+	headerHeight := lipgloss.Height(m.headerView())
+	footerHeight := lipgloss.Height(m.footerView())
+	m.viewport.YPosition = headerHeight
+	m.viewport.Height = max(0, m.height-headerHeight-footerHeight)
+}
+
 // Pagination Helpers
 func (m *model) totalTorrentPages(perPage int) int {
 	if len(m.torrents) == 0 {
@@ -2,6 +2,10 @@ package main
 
 import (
 	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -84,6 +88,43 @@ func formatTime(ts int) string {
 	}
 }
 
+// Format seconds until an event as a short countdown (e.g. "2d 3h")
+func formatCountdown(seconds int64) string {
+	if seconds <= 0 {
+		return "now"
+	}
+	d := time.Duration(seconds) * time.Second
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", max(1, minutes))
+	}
+}
+
+var (
+	htmlBreakRe = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlTagRe   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// stripHTML turns AniList's HTML descriptions into plain text
+func stripHTML(s string) string {
+	s = htmlBreakRe.ReplaceAllString(s, "\n")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\r", "")
+	for strings.Contains(s, "\n\n\n") {
+		s = strings.ReplaceAll(s, "\n\n\n", "\n\n")
+	}
+	return strings.TrimSpace(s)
+}
+
 // Format bytes (moved from main.go for organization)
 func formatBytes(bytes int64) string {
 	const unit = 1024
@@ -256,3 +297,33 @@ func wrapText(text string, width int) string {
 
 	return result.String()
 }
+
+// Episode number patterns seen in release file names, most specific first
+var episodePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bS\d{1,2}E(\d{1,4})\b`),
+	regexp.MustCompile(`(?i)\b(?:EP?|Episode)\s?(\d{1,4})\b`),
+	regexp.MustCompile(`\s-\s(\d{1,4})(?:v\d)?(?:\s|$|\[|\()`),
+	regexp.MustCompile(`\[(\d{1,4})(?:v\d)?\]`),
+}
+
+// parseEpisodeNumber extracts the episode number from a release file name.
+// Numbers that look like a year ("[2019]") are skipped. It returns 0 when no
+// episode number can be found.
+func parseEpisodeNumber(name string) int {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if ext := path.Ext(name); len(ext) <= 5 && !strings.Contains(ext, " ") {
+		name = strings.TrimSuffix(name, ext)
+	}
+	name = strings.ReplaceAll(name, "_", " ")
+
+	for _, re := range episodePatterns {
+		for _, match := range re.FindAllStringSubmatch(name, -1) {
+			episode, err := strconv.Atoi(match[1])
+			if err != nil || len(match[1]) == 4 && episode >= 1900 && episode < 2100 {
+				continue
+			}
+			return episode
+		}
+	}
+	return 0
+}
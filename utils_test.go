@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestParseEpisodeNumber(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"[SubsPlease] Dandadan - 05 (1080p) [A1B2C3D4].mkv", 5},
+		{"[Erai-raws] Dandadan - 12v2 [1080p].mkv", 12},
+		{"Show.S02E07.1080p.WEB.mkv", 7},
+		{"Show Episode 3 [720p].mp4", 3},
+		{"Show EP11.mkv", 11},
+		{"[Group] Show [04][1080p].mkv", 4},
+		{"[Group] Show [2019][04].mkv", 4},
+		{"[Group] Show [04][2019].mkv", 4},
+		{"[Group] Show (2019) - 08 [1080p].mkv", 8},
+		{"[Group] Movie [2019].mkv", 0},
+		{"Movie - 2019 [1080p].mkv", 0},
+		{"C:\\Downloads\\Show - 09.mkv", 9},
+		{"/downloads/Season 1/Show_-_10_[1080p].mkv", 10},
+		{"Show - 1001 [1080p].mkv", 1001},
+		{"Show The Movie.mkv", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := parseEpisodeNumber(tt.name); got != tt.want {
+			t.Errorf("parseEpisodeNumber(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Plain text", "Plain text"},
+		{"One<br>Two<BR/>Three<br />Four", "One\nTwo\nThree\nFour"},
+		{"<i>Italic</i> and <b>bold</b>", "Italic and bold"},
+		{"Tom &amp; Jerry &quot;quoted&quot; &#39;single&#39;", `Tom & Jerry "quoted" 'single'`},
+		{"First<br><br><br><br>Second", "First\n\nSecond"},
+		{"Line\r\n<br>\r\nNext", "Line\n\nNext"},
+		{"  <br>Padded<br>  ", "Padded"},
+		{"(Source: <a href=\"https://example.com\">Example</a>)", "(Source: Example)"},
+	}
+	for _, tt := range tests {
+		if got := stripHTML(tt.in); got != tt.want {
+			t.Errorf("stripHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}